@@ -0,0 +1,511 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/itchyny/gojq"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagAPIField    = "field"
+	FlagAPIRawField = "raw-field"
+	FlagAPIHeader   = "header"
+	FlagAPIInclude  = "include"
+	FlagAPIInput    = "input"
+	FlagAPIJQ       = "jq"
+	FlagAPIPaginate = "paginate"
+	FlagAPIMaxPages = "max-pages"
+
+	QueryPage = "page"
+)
+
+// apiCmd represents the api command
+var apiCmd = &cobra.Command{
+	Use:   "api <METHOD> <path>",
+	Short: "send an authenticated request to any CasaOS API endpoint",
+	Long: `Send an authenticated request to any CasaOS API endpoint, and print the response.
+
+The root url and the access token are resolved the same way as other commands. Fields given
+via --raw-field/--field are sent as a JSON body, or as query parameters for GET requests.
+
+Examples:
+  casaos-cli api GET /v2/app_management/web/appgrid
+  casaos-cli api GET /v2/app_management/web/appgrid --jq '.data[].name'
+  casaos-cli api PUT /v1/users/current -f nickname=casaos
+  casaos-cli api POST /v2/app_management/compose --input docker-compose.yml -H 'Content-Type: application/yaml'`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method := strings.ToUpper(args[0])
+		path := args[1]
+
+		header, err := apiHeaderFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		fields, err := apiFieldsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		input, err := cmd.Flags().GetString(FlagAPIInput)
+		if err != nil {
+			return err
+		}
+
+		if input != "" && len(fields) > 0 && method != http.MethodGet {
+			return fmt.Errorf("--%s cannot be used together with --%s or --%s for %s requests", FlagAPIInput, FlagAPIRawField, FlagAPIField, method)
+		}
+
+		body, err := readAPIInput(input)
+		if err != nil {
+			return err
+		}
+
+		query := url.Values{}
+		if len(fields) > 0 {
+			if method == http.MethodGet {
+				for key, value := range fields {
+					query.Set(key, fmt.Sprint(value))
+				}
+			} else {
+				if body, err = json.Marshal(fields); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(body) > 0 && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", MINEApplicationJSON)
+		}
+
+		code, err := apiJQFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		include, err := cmd.Flags().GetBool(FlagAPIInclude)
+		if err != nil {
+			return err
+		}
+
+		paginate, err := cmd.Flags().GetBool(FlagAPIPaginate)
+		if err != nil {
+			return err
+		}
+
+		maxPages, err := cmd.Flags().GetInt(FlagAPIMaxPages)
+		if err != nil {
+			return err
+		}
+
+		if paginate && method != http.MethodGet {
+			return fmt.Errorf("--%s is only supported for GET requests", FlagAPIPaginate)
+		}
+
+		path, query, err = apiPathAndQuery(path, query)
+		if err != nil {
+			return err
+		}
+
+		var previous []byte
+
+		for pages := 1; ; pages++ {
+			requestPath := path
+			if len(query) > 0 {
+				requestPath += "?" + query.Encode()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+			response, buf, err := apiRequest(ctx, method, requestPath, header, bytes.NewReader(body))
+			cancel()
+			if err != nil {
+				return err
+			}
+
+			// a server that ignores the page parameter returns the same page again, which is not printed twice
+			if previous != nil && bytes.Equal(buf, previous) {
+				return nil
+			}
+
+			if include {
+				printAPIResponseHeader(cmd.OutOrStdout(), response)
+			}
+
+			if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+				if outputFormat() == OutputText {
+					printAPIResponseBody(cmd.ErrOrStderr(), buf)
+				}
+				return &casaos.StatusError{StatusCode: response.StatusCode, Status: response.Status, Message: method + " " + requestPath}
+			}
+
+			if err := printAPIResponse(cmd.OutOrStdout(), buf, code); err != nil {
+				return err
+			}
+
+			if !paginate || !nextAPIPage(query, buf) {
+				return nil
+			}
+
+			if maxPages > 0 && pages >= maxPages {
+				fmt.Fprintf(cmd.ErrOrStderr(), "stopped after %d pages, use --%s to fetch more\n", pages, FlagAPIMaxPages)
+				return nil
+			}
+
+			previous = buf
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// apiCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// apiCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	addAPIRequestFlags(apiCmd)
+	apiCmd.Flags().Bool(FlagAPIPaginate, false, "fetch all pages by incrementing the 'page' query parameter until an empty, repeated or last page is returned")
+	apiCmd.Flags().Int(FlagAPIMaxPages, 100, "maximum number of pages to fetch with --paginate (0 means no limit)")
+}
+
+func addAPIRequestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP(FlagAPIRawField, "f", []string{}, "add a string field in key=value format")
+	cmd.Flags().StringArrayP(FlagAPIField, "F", []string{}, "add a typed field in key=value format (true, false, null and numbers are converted, and @file reads the value from file)")
+	cmd.Flags().StringArrayP(FlagAPIHeader, "H", []string{}, "add a HTTP request header in 'key: value' format")
+	cmd.Flags().BoolP(FlagAPIInclude, "i", false, "include HTTP response status and headers in the output")
+	cmd.Flags().String(FlagAPIInput, "", "file to use as request body ('-' to read from standard input)")
+	cmd.Flags().StringP(FlagAPIJQ, "q", "", "filter the JSON response with a jq expression")
+}
+
+// apiRequest sends a request to the given path under the root url, with the access token attached when available.
+// Unlike v1Request, the response is returned as is regardless of its status code.
+func apiRequest(ctx context.Context, method, path string, header http.Header, body io.Reader) (*http.Response, []byte, error) {
+	rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("http://%s/%s", rootURL, strings.TrimLeft(path, "/")), body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for key, values := range header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+
+	if token, err := accessToken(); err == nil && token != "" && request.Header.Get("Authorization") == "" {
+		request.Header.Set("Authorization", token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, buf, nil
+}
+
+func apiHeaderFromFlags(cmd *cobra.Command) (http.Header, error) {
+	headers, err := cmd.Flags().GetStringArray(FlagAPIHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for _, h := range headers {
+		key, value, found := strings.Cut(h, ":")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid header %s - should be in 'key: value' format", h)
+		}
+
+		header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	return header, nil
+}
+
+func apiFieldsFromFlags(cmd *cobra.Command) (map[string]interface{}, error) {
+	rawFields, err := cmd.Flags().GetStringArray(FlagAPIRawField)
+	if err != nil {
+		return nil, err
+	}
+
+	typedFields, err := cmd.Flags().GetStringArray(FlagAPIField)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+
+	for _, f := range rawFields {
+		key, value, err := parseAPIField(f)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[key] = value
+	}
+
+	for _, f := range typedFields {
+		key, value, err := parseAPIField(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if fields[key], err = typedAPIFieldValue(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
+
+func apiJQFromFlags(cmd *cobra.Command) (*gojq.Code, error) {
+	filter, err := cmd.Flags().GetString(FlagAPIJQ)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == "" {
+		return nil, nil
+	}
+
+	query, err := gojq.Parse(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq filter: %w", err)
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq filter: %w", err)
+	}
+
+	return code, nil
+}
+
+func readAPIInput(input string) ([]byte, error) {
+	switch input {
+	case "":
+		return nil, nil
+	case "-":
+		return io.ReadAll(os.Stdin)
+	default:
+		return os.ReadFile(input)
+	}
+}
+
+func parseAPIField(field string) (string, string, error) {
+	key, value, found := strings.Cut(field, "=")
+	if !found || key == "" {
+		return "", "", fmt.Errorf("invalid field %s - should be in key=value format", field)
+	}
+
+	return key, value, nil
+}
+
+func typedAPIFieldValue(value string) (interface{}, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if strings.HasPrefix(value, "@") {
+		buf, err := os.ReadFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return nil, err
+		}
+
+		return string(buf), nil
+	}
+
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f, nil
+	}
+
+	return value, nil
+}
+
+// apiPathAndQuery splits any query string in the path, and merges it into the query from fields.
+func apiPathAndQuery(path string, query url.Values) (string, url.Values, error) {
+	path, rawQuery, _ := strings.Cut(path, "?")
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for key, value := range query {
+		values[key] = value
+	}
+
+	return "/" + strings.TrimLeft(path, "/"), values, nil
+}
+
+// apiPageMetadata are keys that tell a response is a page of a list, either in `data` or at the top level.
+var apiPageMetadata = []string{"page", "page_size", "size", "total", "total_count", "total_page", "has_more"}
+
+// nextAPIPage increments the `page` query parameter, unless the page just fetched does not look like a
+// non-empty page of a list with pagination metadata, or it tells there are no more pages, in which case there is
+// nothing more to fetch.
+func nextAPIPage(query url.Values, buf []byte) bool {
+	data := json.Get(buf, "data")
+	if data.LastError() != nil {
+		data = json.Get(buf)
+	}
+
+	items := data
+	if items.ValueType() != jsoniter.ArrayValue {
+		for _, key := range []string{"list", "items", "content"} {
+			if items = data.Get(key); items.ValueType() == jsoniter.ArrayValue {
+				break
+			}
+		}
+	}
+
+	if items.ValueType() != jsoniter.ArrayValue || items.Size() == 0 {
+		return false
+	}
+
+	metadata := func(key string) jsoniter.Any {
+		if value := data.Get(key); value.LastError() == nil {
+			return value
+		}
+		return json.Get(buf, key)
+	}
+
+	found := false
+	for _, key := range apiPageMetadata {
+		if metadata(key).LastError() == nil {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return false
+	}
+
+	if hasMore := metadata("has_more"); hasMore.ValueType() == jsoniter.BoolValue && !hasMore.ToBool() {
+		return false
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(query.Get(QueryPage)); err == nil {
+		page = p
+	}
+
+	if totalPage := metadata("total_page"); totalPage.ValueType() == jsoniter.NumberValue && page >= totalPage.ToInt() {
+		return false
+	}
+
+	query.Set(QueryPage, strconv.Itoa(page+1))
+
+	return true
+}
+
+func printAPIResponseHeader(w io.Writer, response *http.Response) {
+	fmt.Fprintf(w, "%s %s\n", response.Proto, response.Status)
+	response.Header.Write(w)
+	fmt.Fprintln(w)
+}
+
+// printAPIResponse prints the response body, filtered by the jq code if given.
+func printAPIResponse(w io.Writer, buf []byte, code *gojq.Code) error {
+	if code == nil {
+		printAPIResponseBody(w, buf)
+		return nil
+	}
+
+	return printAPIResponseFiltered(w, buf, code)
+}
+
+func printAPIResponseBody(w io.Writer, buf []byte) {
+	var out bytes.Buffer
+	if err := stdjson.Indent(&out, buf, "", "  "); err != nil {
+		w.Write(buf)
+		if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+		return
+	}
+
+	fmt.Fprintln(w, out.String())
+}
+
+func printAPIResponseFiltered(w io.Writer, buf []byte, code *gojq.Code) error {
+	var input interface{}
+	if err := stdjson.Unmarshal(buf, &input); err != nil {
+		return fmt.Errorf("response is not JSON, cannot be filtered: %w", err)
+	}
+
+	iter := code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+
+		switch v := v.(type) {
+		case error:
+			return v
+		case string:
+			fmt.Fprintln(w, v)
+		default:
+			output, err := gojq.Marshal(v)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, string(output))
+		}
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagAPIParam = "param"
+)
+
+// apiCallCmd represents the apiCall command
+var apiCallCmd = &cobra.Command{
+	Use:   "call <operationId>",
+	Short: "call an operation from the OpenAPI spec, with parameters and request body validated against the spec",
+	Long: `Call an operation from the OpenAPI spec, with parameters and request body validated against the spec.
+
+Path, query and header parameters are given via --param. The request body is given either via
+--raw-field/--field for JSON bodies, or via --input.
+
+Examples:
+  casaos-cli api call MyComposeApp --param id=syncthing
+  casaos-cli api call app_management.ComposeAppStoreInfoList --param category=Utilities --jq '.data | keys'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		operations, err := loadAPIOperations(cmd)
+		if err != nil {
+			return err
+		}
+
+		operation, err := findAPIOperation(operations, args[0])
+		if err != nil {
+			return err
+		}
+
+		params, err := cmd.Flags().GetStringArray(FlagAPIParam)
+		if err != nil {
+			return err
+		}
+
+		values := map[string]string{}
+		for _, p := range params {
+			key, value, err := parseAPIField(p)
+			if err != nil {
+				return err
+			}
+			values[key] = value
+		}
+
+		header, err := apiHeaderFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, query, err := operation.bindParameters(values, header)
+		if err != nil {
+			return err
+		}
+
+		body, err := operation.requestBodyFromFlags(cmd, header)
+		if err != nil {
+			return err
+		}
+
+		code, err := apiJQFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		include, err := cmd.Flags().GetBool(FlagAPIInclude)
+		if err != nil {
+			return err
+		}
+
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		response, buf, err := apiRequest(ctx, operation.Method, path, header, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		if include {
+			printAPIResponseHeader(cmd.OutOrStdout(), response)
+		}
+
+		if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+			if outputFormat() == OutputText {
+				printAPIResponseBody(cmd.ErrOrStderr(), buf)
+			}
+			return &casaos.StatusError{StatusCode: response.StatusCode, Status: response.Status, Message: operation.Method + " " + path}
+		}
+
+		return printAPIResponse(cmd.OutOrStdout(), buf, code)
+	},
+}
+
+func init() {
+	apiCmd.AddCommand(apiCallCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// apiCallCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// apiCallCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	addOpenAPIFlags(apiCallCmd)
+	addAPIRequestFlags(apiCallCmd)
+	apiCallCmd.Flags().StringArrayP(FlagAPIParam, "p", []string{}, "add a path, query or header parameter in key=value format")
+}
+
+// bindParameters validates parameter values against the spec, and puts them into path, query and header accordingly.
+func (o *apiOperation) bindParameters(values map[string]string, header http.Header) (string, url.Values, error) {
+	path := o.BasePath + o.Path
+	query := url.Values{}
+	cookies := []string{}
+
+	for _, p := range o.Parameters {
+		value, ok := values[p.Name]
+		if !ok {
+			if p.Required || p.In == "path" {
+				return "", nil, fmt.Errorf("required %s parameter %s is missing - see `casaos-cli api describe %s`", p.In, p.Name, o.Operation.OperationID)
+			}
+			continue
+		}
+
+		if err := validateSchemaValue(o.spec.resolveSchema(p.Schema), value); err != nil {
+			return "", nil, fmt.Errorf("invalid parameter %s: %w", p.Name, err)
+		}
+
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		case "query":
+			query.Set(p.Name, value)
+		case "header":
+			header.Set(p.Name, value)
+		case "cookie":
+			cookies = append(cookies, (&http.Cookie{Name: p.Name, Value: value}).String())
+		}
+	}
+
+	if len(cookies) > 0 {
+		header.Add("Cookie", strings.Join(cookies, "; "))
+	}
+
+	for name := range values {
+		if !lo.ContainsBy(o.Parameters, func(p openAPIParameter) bool { return p.Name == name }) {
+			names := lo.Map(o.Parameters, func(p openAPIParameter, _ int) string { return p.Name })
+			if len(names) == 0 {
+				return "", nil, fmt.Errorf("unknown parameter %s - operation %s takes no parameter", name, o.Operation.OperationID)
+			}
+			return "", nil, fmt.Errorf("unknown parameter %s - should be one of %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	return path, query, nil
+}
+
+// requestBodyFromFlags builds the request body from fields or input file, and validates JSON bodies against the spec.
+func (o *apiOperation) requestBodyFromFlags(cmd *cobra.Command, header http.Header) ([]byte, error) {
+	fields, err := apiFieldsFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := cmd.Flags().GetString(FlagAPIInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if input != "" && len(fields) > 0 {
+		return nil, fmt.Errorf("--%s cannot be used together with --%s or --%s", FlagAPIInput, FlagAPIRawField, FlagAPIField)
+	}
+
+	if o.RequestBody == nil || len(o.RequestBody.Content) == 0 {
+		if input != "" || len(fields) > 0 {
+			return nil, fmt.Errorf("operation %s does not take a request body", o.Operation.OperationID)
+		}
+		return nil, nil
+	}
+
+	if input == "" && len(fields) == 0 {
+		if o.RequestBody.Required {
+			return nil, fmt.Errorf("operation %s requires a request body - use --%s, --%s or --%s", o.Operation.OperationID, FlagAPIInput, FlagAPIRawField, FlagAPIField)
+		}
+		return nil, nil
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentTypes := lo.Keys(o.RequestBody.Content)
+		sort.Strings(contentTypes)
+
+		contentType = contentTypes[0]
+		if lo.Contains(contentTypes, MINEApplicationJSON) {
+			contentType = MINEApplicationJSON
+		}
+
+		header.Set("Content-Type", contentType)
+	}
+
+	content, ok := o.RequestBody.Content[contentType]
+	if !ok {
+		return nil, fmt.Errorf("content type %s is not accepted by operation %s - should be one of %s", contentType, o.Operation.OperationID, strings.Join(lo.Keys(o.RequestBody.Content), ", "))
+	}
+
+	isJSON := strings.HasPrefix(contentType, MINEApplicationJSON)
+
+	if len(fields) > 0 {
+		if !isJSON {
+			return nil, fmt.Errorf("fields can only be used for JSON request body - use --%s for %s", FlagAPIInput, contentType)
+		}
+
+		// round trip to get the same value types as decoding an input file
+		buf, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		var object map[string]interface{}
+		if err := json.Unmarshal(buf, &object); err != nil {
+			return nil, err
+		}
+
+		if err := o.spec.validateSchemaObject(content.Schema, object); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+
+		return buf, nil
+	}
+
+	buf, err := readAPIInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if isJSON {
+		var body interface{}
+		if err := json.Unmarshal(buf, &body); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+
+		if object, ok := body.(map[string]interface{}); ok {
+			if err := o.spec.validateSchemaObject(content.Schema, object); err != nil {
+				return nil, fmt.Errorf("invalid request body: %w", err)
+			}
+		}
+	}
+
+	return buf, nil
+}
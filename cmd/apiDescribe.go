@@ -0,0 +1,158 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// apiDescribeCmd represents the apiDescribe command
+var apiDescribeCmd = &cobra.Command{
+	Use:   "describe <operationId>",
+	Short: "describe an operation from the OpenAPI spec, including its parameters and schemas",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		operations, err := loadAPIOperations(cmd)
+		if err != nil {
+			return err
+		}
+
+		operation, err := findAPIOperation(operations, args[0])
+		if err != nil {
+			return err
+		}
+
+		spec := operation.spec
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "Operation ID:\t%s\n", operation.Operation.OperationID)
+		fmt.Fprintf(w, "Service:\t%s\n", operation.Service)
+		fmt.Fprintf(w, "Method:\t%s\n", operation.Method)
+		fmt.Fprintf(w, "Path:\t%s\n", operation.BasePath+operation.Path)
+		fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(operation.Operation.Tags, ", "))
+		fmt.Fprintf(w, "Summary:\t%s\n", operation.Operation.Summary)
+
+		if description := strings.TrimSpace(operation.Operation.Description); description != "" {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, description)
+		}
+
+		if len(operation.Parameters) > 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "PARAMETER\tIN\tTYPE\tREQUIRED\tDESCRIPTION")
+			fmt.Fprintln(w, "---------\t--\t----\t--------\t-----------")
+
+			for _, p := range operation.Parameters {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", p.Name, p.In, describeSchemaType(spec, p.Schema), p.Required, trim(firstLine(p.Description), 60))
+			}
+		}
+
+		if operation.RequestBody != nil {
+			for contentType, content := range operation.RequestBody.Content {
+				fmt.Fprintln(w)
+				fmt.Fprintf(w, "Request Body:\t%s (%s, required: %t)\n", schemaType(content.Schema), contentType, operation.RequestBody.Required)
+				printSchemaProperties(w, spec, content.Schema)
+			}
+		}
+
+		if len(operation.Operation.Responses) > 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "RESPONSE\tTYPE\tDESCRIPTION")
+			fmt.Fprintln(w, "--------\t----\t-----------")
+
+			codes := lo.Keys(operation.Operation.Responses)
+			sort.Strings(codes)
+
+			for _, code := range codes {
+				response := spec.resolveResponse(operation.Operation.Responses[code])
+
+				types := lo.Uniq(lo.MapToSlice(response.Content, func(_ string, content openAPIMediaType) string {
+					return schemaType(content.Schema)
+				}))
+				sort.Strings(types)
+
+				fmt.Fprintf(w, "%s\t%s\t%s\n", code, strings.Join(types, ", "), trim(firstLine(response.Description), 60))
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	apiCmd.AddCommand(apiDescribeCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// apiDescribeCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// apiDescribeCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	addOpenAPIFlags(apiDescribeCmd)
+}
+
+// describeSchemaType is like schemaType, but also shows enum values of a referenced schema.
+func describeSchemaType(spec *openAPISpec, schema *openAPISchema) string {
+	t := schemaType(schema)
+
+	if resolved := spec.resolveSchema(schema); resolved != nil && len(resolved.Enum) > 0 {
+		values := lo.Map(resolved.Enum, func(v interface{}, _ int) string { return fmt.Sprint(v) })
+		t += " (" + strings.Join(values, "|") + ")"
+	}
+
+	return t
+}
+
+func printSchemaProperties(w *tabwriter.Writer, spec *openAPISpec, schema *openAPISchema) {
+	resolved := spec.resolveSchema(schema)
+	if resolved == nil || len(resolved.Properties) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "PROPERTY\tTYPE\tREQUIRED\tDESCRIPTION")
+	fmt.Fprintln(w, "--------\t----\t--------\t-----------")
+
+	names := lo.Keys(resolved.Properties)
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := resolved.Properties[name]
+
+		description := ""
+		if p := spec.resolveSchema(property); p != nil {
+			description = p.Description
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", name, describeSchemaType(spec, property), lo.Contains(resolved.Required, name), trim(firstLine(description), 60))
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// apiListCmd represents the apiList command
+var apiListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list operations from the OpenAPI spec served by each CasaOS service",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		operations, err := loadAPIOperations(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(operations) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No operation found")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "SERVICE\tOPERATION ID\tMETHOD\tPATH\tSUMMARY")
+		fmt.Fprintln(w, "-------\t------------\t------\t----\t-------")
+
+		for _, operation := range operations {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				operation.Service,
+				operation.Operation.OperationID,
+				operation.Method,
+				operation.BasePath+operation.Path,
+				trim(operation.Operation.Summary, 60),
+			)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	apiCmd.AddCommand(apiListCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// apiListCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// apiListCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	addOpenAPIFlags(apiListCmd)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "testing"
+
+func TestAPI(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "api", "GET", "/v2/app_management/appstore")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "api_get", stdout)
+}
+
+func TestAPIJQ(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "api", "GET", "/v2/app_management/appstore", "--"+FlagAPIJQ, ".data[].url")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "api_get_jq", stdout)
+}
+
+func TestAPINotFound(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "api", "GET", "/v2/unknown")
+	assertExitCode(t, ExitCodeNotFound, code, stderr)
+	assertGolden(t, "api_not_found", stdout+stderr)
+}
+
+func TestAPIPaginateRepeatedPage(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	// the mock server ignores the page parameter, so the second page is the same as the first one
+	stdout, stderr, code := runCommand(t, server, "api", "GET", "/v2/paged/ignores_page", "--"+FlagAPIPaginate, "--"+FlagAPIJQ, ".data.list[].name")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "api_paginate_repeated_page", stdout)
+}
@@ -24,6 +24,7 @@ const (
 	FlagAppManagementUseColor = "color"
 	FlagAppManagementStoreURL = "app-store-url"
 	FlagAppManagementStoreID  = "app-store-id"
+	FlagAppManagementLanguage = "lang"
 
 	MINEApplicationJSON = "application/json"
 	MIMEApplicationYAML = "application/yaml"
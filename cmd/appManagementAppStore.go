@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// appManagementAppStoreCmd represents the appManagementAppStore command
+var appManagementAppStoreCmd = &cobra.Command{
+	Use:     "app-store",
+	Short:   "create, validate, build and serve app store directories",
+	Aliases: []string{"appstore"},
+}
+
+func init() {
+	appManagementCmd.AddCommand(appManagementAppStoreCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementAppStoreCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementAppStoreCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
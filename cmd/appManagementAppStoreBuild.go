@@ -0,0 +1,141 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// appManagementAppStoreBuildCmd represents the appManagementAppStoreBuild command
+var appManagementAppStoreBuildCmd = &cobra.Command{
+	Use:   "build <dir>",
+	Short: "validate an app store directory and build its ZIP file for distribution",
+	Long: `Validate an app store directory the same way as "app-store validate", except against registered app stores,
+and build the ZIP file to host for "register app-store <url>".
+
+The ZIP file has a SHA256SUMS manifest with the checksum of every file in it, and its own checksum is written next
+to it as <file>.sha256, so both can be verified with sha256sum -c.`,
+	Example: `  casaos-cli app-management app-store build ./my-store -f dist/my-store.zip`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		strict, err := cmd.Flags().GetBool(FlagAppStoreStrict)
+		if err != nil {
+			return err
+		}
+
+		filename, err := cmd.Flags().GetString(FlagFile)
+		if err != nil {
+			return err
+		}
+
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		store, err := loadStoreDir(root)
+		if err != nil {
+			return err
+		}
+
+		store.lint()
+
+		if err := reportStoreIssues(cmd.ErrOrStderr(), store, strict); err != nil {
+			return err
+		}
+
+		name := filepath.Base(root)
+
+		if filename == "" {
+			filename = name + ".zip"
+		}
+
+		checksum, err := writeStoreArchiveFile(filename, root, name)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filename+".sha256", []byte(fmt.Sprintf("%s  %s\n", checksum, filepath.Base(filename))), 0o644); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "built %s with %d app(s)\n", filename, len(store.Apps))
+		fmt.Fprintf(cmd.OutOrStdout(), "sha256: %s\n", checksum)
+
+		return nil
+	},
+}
+
+func init() {
+	appManagementAppStoreCmd.AddCommand(appManagementAppStoreBuildCmd)
+
+	appManagementAppStoreBuildCmd.Flags().StringP(FlagFile, "f", "", "path of the ZIP file to write (default to <dir>.zip in current directory)")
+	appManagementAppStoreBuildCmd.Flags().Bool(FlagAppStoreStrict, false, "fail on warnings as well as errors")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementAppStoreBuildCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementAppStoreBuildCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// writeStoreArchiveFile writes the app store directory with a manifest to a ZIP file, and returns its checksum. The
+// file is written to a temporary file first, so a failed build does not leave a broken ZIP file behind.
+func writeStoreArchiveFile(filename, root, prefix string) (string, error) {
+	if dir := filepath.Dir(filename); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	hash := sha256.New()
+
+	if err := writeStoreArchive(io.MultiWriter(f, hash), root, prefix, true); err != nil {
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(f.Name(), filename); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
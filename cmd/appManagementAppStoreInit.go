@@ -0,0 +1,254 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagAppStoreApp       = "app"
+	FlagAppStoreAssetsURL = "assets-url"
+
+	DefaultAppStoreApp       = "hello-world"
+	DefaultAppStoreAssetsURL = "https://cdn.jsdelivr.net/gh/OWNER/REPO@main"
+)
+
+var storeAppIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// appManagementAppStoreInitCmd represents the appManagementAppStoreInit command
+var appManagementAppStoreInitCmd = &cobra.Command{
+	Use:   "init <dir>",
+	Short: "create an app store directory with an example app",
+	Long: `Create an app store directory with an example app, laid out like https://github.com/IceWhaleTech/CasaOS-AppStore:
+
+  Apps/<app>/docker-compose.yml   compose file of each app, with store info in x-casaos
+  Apps/<app>/icon.png             icon and screenshots of each app, as placeholders to replace
+  category-list.json              categories of apps
+  recommend-list.json             ids of recommended apps
+  README.md                       how to add, validate, build and serve apps
+
+Icons and screenshots are linked via --assets-url, where the app store repository is published, e.g. jsDelivr for a
+GitHub repository.`,
+	Example: `  casaos-cli app-management app-store init ./my-store --app my-app --assets-url https://cdn.jsdelivr.net/gh/me/my-store@main`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID, err := cmd.Flags().GetString(FlagAppStoreApp)
+		if err != nil {
+			return err
+		}
+
+		assetsURL, err := cmd.Flags().GetString(FlagAppStoreAssetsURL)
+		if err != nil {
+			return err
+		}
+
+		force, err := cmd.Flags().GetBool(FlagForce)
+		if err != nil {
+			return err
+		}
+
+		if !storeAppIDPattern.MatchString(appID) {
+			return usageError{err: fmt.Errorf("invalid app id %s - use lowercase letters, digits, - and _", appID)}
+		}
+
+		root := args[0]
+
+		if entries, err := os.ReadDir(root); err == nil && len(entries) > 0 && !force {
+			return fmt.Errorf("%s is not empty - use --%s to write into it anyway, overwriting files with the same names", root, FlagForce)
+		}
+
+		data := storeTemplateData{
+			AppID:     appID,
+			AppDir:    appID,
+			Title:     storeAppTitle(appID),
+			AssetsURL: strings.TrimSuffix(assetsURL, "/"),
+		}
+
+		files := map[string]string{
+			"README.md":            storeReadmeTemplate,
+			StoreCategoryListFile:  storeCategoryListTemplate,
+			StoreRecommendListFile: storeRecommendListTemplate,
+			filepath.Join(StoreAppsDir, data.AppDir, StoreComposeFile): storeComposeTemplate,
+		}
+
+		for name, text := range files {
+			var buf bytes.Buffer
+			if err := template.Must(template.New(name).Parse(text)).Execute(&buf, data); err != nil {
+				return err
+			}
+
+			if err := writeStoreFile(filepath.Join(root, name), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+
+		for name, size := range map[string]image.Point{
+			"icon.png":         {192, 192},
+			"thumbnail.png":    {784, 442},
+			"screenshot-1.png": {1280, 720},
+		} {
+			buf, err := placeholderPNG(size)
+			if err != nil {
+				return err
+			}
+
+			if err := writeStoreFile(filepath.Join(root, StoreAppsDir, data.AppDir, name), buf); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "created app store %s with example app %s\n", root, appID)
+		fmt.Fprintf(cmd.OutOrStdout(), "validate it with: casaos-cli app-management app-store validate %s\n", root)
+
+		return nil
+	},
+}
+
+func init() {
+	appManagementAppStoreCmd.AddCommand(appManagementAppStoreInitCmd)
+
+	appManagementAppStoreInitCmd.Flags().String(FlagAppStoreApp, DefaultAppStoreApp, "store app id of the example app")
+	appManagementAppStoreInitCmd.Flags().String(FlagAppStoreAssetsURL, DefaultAppStoreAssetsURL, "URL where the app store repository is published, to link icons and screenshots")
+	appManagementAppStoreInitCmd.Flags().Bool(FlagForce, false, "write into a directory that is not empty")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementAppStoreInitCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementAppStoreInitCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+type storeTemplateData struct {
+	AppID     string
+	AppDir    string
+	Title     string
+	AssetsURL string
+}
+
+// storeAppTitle turns an app id like `hello-world` into a title like `Hello World`.
+func storeAppTitle(appID string) string {
+	words := strings.FieldsFunc(appID, func(r rune) bool { return r == '-' || r == '_' })
+
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+func writeStoreFile(path string, buf []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf, 0o644)
+}
+
+// placeholderPNG returns a PNG image of a plain color, to be replaced by a real icon or screenshot.
+func placeholderPNG(size image.Point) ([]byte, error) {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 0x4a, G: 0x90, B: 0xe2, A: 0xff}}, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+const storeComposeTemplate = `name: {{.AppID}}
+services:
+  {{.AppID}}:
+    image: nginxdemos/hello:0.3
+    restart: unless-stopped
+    ports:
+      - target: 80
+        published: "8080"
+        protocol: tcp
+    x-casaos:
+      ports:
+        - container: "80"
+          description:
+            en_us: WebUI HTTP Port
+x-casaos:
+  architectures:
+    - amd64
+    - arm64
+  main: {{.AppID}}
+  author: Me
+  developer: NGINX
+  category: Utilities
+  icon: {{.AssetsURL}}/Apps/{{.AppDir}}/icon.png
+  thumbnail: {{.AssetsURL}}/Apps/{{.AppDir}}/thumbnail.png
+  screenshot_link:
+    - {{.AssetsURL}}/Apps/{{.AppDir}}/screenshot-1.png
+  tagline:
+    en_us: A web page saying hello
+  title:
+    en_us: {{.Title}}
+  description:
+    en_us: |
+      {{.Title}} serves a web page showing the hostname and address of its container.
+  tips:
+    before_install:
+      en_us: Nothing to prepare before installing.
+  scheme: http
+  port_map: "8080"
+  index: /
+`
+
+const storeCategoryListTemplate = `[
+  {"name": "Backup", "font": "backup-restore", "description": "Back up and sync files"},
+  {"name": "Cloud", "font": "cloud", "description": "Store and share files"},
+  {"name": "Developer", "font": "code-braces", "description": "Build and run software"},
+  {"name": "Media", "font": "play-circle", "description": "Watch and listen"},
+  {"name": "Network", "font": "lan", "description": "Manage networks"},
+  {"name": "Utilities", "font": "toolbox", "description": "Useful tools"}
+]
+`
+
+const storeRecommendListTemplate = `[
+  {"appid": "{{.AppID}}"}
+]
+`
+
+const storeReadmeTemplate = `# App Store
+
+A CasaOS app store. Each app is a compose file at Apps/<app>/docker-compose.yml, with store info in x-casaos, and
+its icon and screenshots next to it.
+
+    casaos-cli app-management app-store validate .    # check every app
+    casaos-cli app-management app-store serve .       # serve it for development
+    casaos-cli app-management app-store build .       # build the ZIP file to host
+`
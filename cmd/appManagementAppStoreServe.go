@@ -0,0 +1,259 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagAppStoreListen   = "listen"
+	FlagAppStoreRegister = "register"
+	FlagAppStoreURL      = "url"
+
+	DefaultAppStoreListen = ":8090"
+)
+
+// appManagementAppStoreServeCmd represents the appManagementAppStoreServe command
+var appManagementAppStoreServeCmd = &cobra.Command{
+	Use:   "serve <dir>",
+	Short: "serve an app store directory over HTTP, as the ZIP file CasaOS expects",
+	Long: `Serve an app store directory over HTTP, so it can be registered without hosting it on the web, e.g. for
+development or on a box without internet access.
+
+The directory is laid out like https://github.com/IceWhaleTech/CasaOS-AppStore:
+
+  Apps/<app>/docker-compose.yml   compose file of each app, with store info in x-casaos
+  category-list.json              categories of apps (optional)
+  recommend-list.json             ids of recommended apps (optional)
+
+The directory is validated before serving, and packaged into a ZIP file again on each request, so changes are
+picked up the next time CasaOS updates the app store. Use --register to register the served app store right away -
+it stays registered after serve stops, until it is unregistered with "unregister app-store <id>".`,
+	Example: `  casaos-cli app-management app-store serve ./my-store --listen :8090 --register`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, err := cmd.Flags().GetString(FlagAppStoreListen)
+		if err != nil {
+			return err
+		}
+
+		register, err := cmd.Flags().GetBool(FlagAppStoreRegister)
+		if err != nil {
+			return err
+		}
+
+		storeURL, err := cmd.Flags().GetString(FlagAppStoreURL)
+		if err != nil {
+			return err
+		}
+
+		if register && storeURL == "" && sshTunnel != nil {
+			return usageError{err: fmt.Errorf("--%s is required to register over --%s, as the remote CasaOS cannot reach this host by a guessed address", FlagAppStoreURL, FlagSSH)}
+		}
+
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		store, err := loadStoreDir(root)
+		if err != nil {
+			return err
+		}
+
+		printStoreIssues(cmd.ErrOrStderr(), store.Issues)
+
+		if errors := store.Errors(); len(errors) > 0 {
+			return fmt.Errorf("app store %s has %d error(s)", root, len(errors))
+		}
+
+		listener, err := net.Listen("tcp", listen)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Base(root)
+		archivePath := "/" + name + ".zip"
+
+		if storeURL == "" {
+			storeURL = storeServeURL(listener.Addr().(*net.TCPAddr), archivePath)
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc(archivePath, func(w http.ResponseWriter, r *http.Request) {
+			serveStoreArchive(w, r, root, name)
+		})
+
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: DefaultTimeout}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- server.Serve(listener)
+		}()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "serving app store %s with %d app(s) at %s\n", root, len(store.Apps), storeURL)
+
+		if register {
+			if err := registerServedStore(cmd.OutOrStdout(), storeURL); err != nil {
+				server.Close()
+				return err
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		select {
+		case err := <-serveErr:
+			return err
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	},
+}
+
+func init() {
+	appManagementAppStoreCmd.AddCommand(appManagementAppStoreServeCmd)
+
+	appManagementAppStoreServeCmd.Flags().StringP(FlagAppStoreListen, "l", DefaultAppStoreListen, "address to listen on, e.g. 127.0.0.1:8090")
+	appManagementAppStoreServeCmd.Flags().Bool(FlagAppStoreRegister, false, "register the served app store to CasaOS")
+	appManagementAppStoreServeCmd.Flags().String(FlagAppStoreURL, "", "URL of the served app store as reachable from CasaOS (default to the address of this host towards CasaOS)")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementAppStoreServeCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementAppStoreServeCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+func printStoreIssues(w io.Writer, issues []storeIssue) {
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue.String())
+	}
+}
+
+// serveStoreArchive validates the app store directory, and serves it as a ZIP file if it has no error. The ETag of
+// the ZIP file is its checksum, so a conditional request gets 304 Not Modified if nothing is changed.
+func serveStoreArchive(w http.ResponseWriter, r *http.Request, root, name string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	store, err := loadStoreDir(root)
+	if err != nil {
+		log.Printf("%s %s - %s", r.Method, r.URL.Path, err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if errors := store.Errors(); len(errors) > 0 {
+		messages := make([]string, 0, len(errors))
+		for _, issue := range errors {
+			messages = append(messages, issue.String())
+		}
+
+		log.Printf("%s %s - app store has %d error(s):\n%s", r.Method, r.URL.Path, len(errors), strings.Join(messages, "\n"))
+		http.Error(w, strings.Join(messages, "\n"), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeStoreArchive(&buf, root, name, false); err != nil {
+		log.Printf("%s %s - %s", r.Method, r.URL.Path, err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	checksum := sha256.Sum256(buf.Bytes())
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(checksum[:])+`"`)
+
+	log.Printf("%s %s from %s - %d app(s), %d bytes", r.Method, r.URL.Path, r.RemoteAddr, len(store.Apps), buf.Len())
+
+	http.ServeContent(w, r, name+".zip", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
+
+// storeServeURL returns the URL of the app store served at addr. If addr is of all interfaces, the address of the
+// interface routing to CasaOS is used, which is 127.0.0.1 if CasaOS runs on this host.
+func storeServeURL(addr *net.TCPAddr, archivePath string) string {
+	ip := addr.IP
+
+	if ip == nil || ip.IsUnspecified() {
+		ip = net.IPv4(127, 0, 0, 1)
+
+		if rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL); err == nil {
+			host := rootURL
+			if h, _, err := net.SplitHostPort(rootURL); err == nil {
+				host = h
+			}
+
+			// no packet is sent by dialing UDP - it only picks the local address routing to host
+			if conn, err := net.Dial("udp", net.JoinHostPort(host, "80")); err == nil {
+				ip = conn.LocalAddr().(*net.UDPAddr).IP
+				conn.Close()
+			}
+		}
+	}
+
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(ip.String(), fmt.Sprint(addr.Port)), archivePath)
+}
+
+func registerServedStore(w io.Writer, storeURL string) error {
+	client, err := casaosClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeDownloadTimeout)
+	defer cancel()
+
+	message, err := client.Store().Register(ctx, storeURL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, message)
+
+	return nil
+}
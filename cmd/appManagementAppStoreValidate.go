@@ -0,0 +1,155 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagAppStoreStrict     = "strict"
+	FlagAppStoreRegistered = "registered"
+)
+
+// appManagementAppStoreValidateCmd represents the appManagementAppStoreValidate command
+var appManagementAppStoreValidateCmd = &cobra.Command{
+	Use:   "validate <dir>",
+	Short: "validate every app in an app store directory",
+	Long: `Validate every app in an app store directory, as laid out by "app-store init":
+
+  - each compose file is valid, with a unique store app id as its name
+  - required store info in x-casaos is set, e.g. main service, author, category, icon and architectures
+  - title, tagline, description and tips have en_us text, with languages like zh_cn
+  - icon, thumbnail and screenshots in the app directory exist, if linked to Apps/<app>/... of the app store
+  - categories and recommended apps are listed in category-list.json and recommend-list.json
+
+Store app ids are also checked against app stores registered to CasaOS, as the same id from another app store is
+reported as a warning - which is expected if this app store is registered itself. The check is skipped if CasaOS
+cannot be reached, or with --registered=false.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		strict, err := cmd.Flags().GetBool(FlagAppStoreStrict)
+		if err != nil {
+			return err
+		}
+
+		checkRegistered, err := cmd.Flags().GetBool(FlagAppStoreRegistered)
+		if err != nil {
+			return err
+		}
+
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		store, err := loadStoreDir(root)
+		if err != nil {
+			return err
+		}
+
+		store.lint()
+
+		if checkRegistered {
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+			defer cancel()
+
+			if err := checkRegisteredStoreApps(ctx, store); err != nil {
+				log.Printf("skipped checking store app ids against registered app stores - %s", err.Error())
+			}
+		}
+
+		return reportStoreIssues(cmd.OutOrStdout(), store, strict)
+	},
+}
+
+func init() {
+	appManagementAppStoreCmd.AddCommand(appManagementAppStoreValidateCmd)
+
+	appManagementAppStoreValidateCmd.Flags().Bool(FlagAppStoreStrict, false, "fail on warnings as well as errors")
+	appManagementAppStoreValidateCmd.Flags().Bool(FlagAppStoreRegistered, true, "check store app ids against app stores registered to CasaOS")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementAppStoreValidateCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementAppStoreValidateCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// checkRegisteredStoreApps adds a warning for each app whose store app id is already provided by an app store
+// registered to CasaOS, as CasaOS shows only one app for the same id.
+func checkRegisteredStoreApps(ctx context.Context, store *storeDir) error {
+	client, err := casaosClient()
+	if err != nil {
+		return err
+	}
+
+	stores, err := client.Store().Stores(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(stores) == 0 {
+		return nil
+	}
+
+	apps, err := client.Store().Apps(ctx, casaos.StoreAppFilter{})
+	if err != nil {
+		return err
+	}
+
+	registered := map[string]bool{}
+	for _, app := range apps {
+		registered[strings.ToLower(app.ID)] = true
+	}
+
+	for _, app := range store.Apps {
+		if registered[strings.ToLower(app.ID)] {
+			store.warnf(filepath.Join(StoreAppsDir, app.Dir, StoreComposeFile), "store app id %s is already provided by one of %d registered app store(s)", app.ID, len(stores))
+		}
+	}
+
+	return nil
+}
+
+// reportStoreIssues prints issues of the app store with a summary, and returns an error if there is any error, or any
+// warning if strict.
+func reportStoreIssues(w io.Writer, store *storeDir, strict bool) error {
+	printStoreIssues(w, store.Issues)
+
+	errors := len(store.Errors())
+	warnings := len(store.Issues) - errors
+
+	fmt.Fprintf(w, "%d app(s) checked - %d error(s), %d warning(s)\n", len(store.Apps), errors, warnings)
+
+	if errors > 0 || (strict && warnings > 0) {
+		return fmt.Errorf("app store %s is invalid", store.Root)
+	}
+
+	return nil
+}
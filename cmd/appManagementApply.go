@@ -17,13 +17,10 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"net/http"
 	"os"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
-	"github.com/samber/lo"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/spf13/cobra"
 )
 
@@ -33,49 +30,42 @@ var appManagementApplyCmd = &cobra.Command{
 	Short: "apply changes to an installed compose app",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
 		appID := cmd.Flags().Arg(0)
 
 		dryRun := cmd.Flag(FlagDryRun).Value.String() == "true"
 
 		filepath := cmd.Flag(FlagFile).Value.String()
 
-		file, err := os.Open(filepath)
+		noPortCheck, err := cmd.Flags().GetBool(FlagAppManagementNoPortCheck)
 		if err != nil {
 			return err
 		}
 
-		client, err := app_management.NewClientWithResponses(url)
+		composeYAML, err := os.ReadFile(filepath)
 		if err != nil {
 			return err
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		params := app_management.ApplyComposeAppSettingsParams{DryRun: lo.ToPtr(dryRun)}
-
-		response, err := client.ApplyComposeAppSettingsWithBodyWithResponse(ctx, appID, &params, MIMEApplicationYAML, file)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if !noPortCheck {
+			if err := preflightPortCheck(ctx, cmd.OutOrStdout(), appID, filepath); err != nil {
+				return err
 			}
+		}
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
+		message, err := client.Apps().Apply(ctx, appID, composeYAML, casaos.ApplyOptions{DryRun: dryRun})
+		if err != nil {
+			return err
 		}
 
-		log.Println(*response.JSON200.Message)
+		log.Println(message)
 
 		return nil
 	},
@@ -85,6 +75,7 @@ func init() {
 	appManagementCmd.AddCommand(appManagementApplyCmd)
 
 	appManagementApplyCmd.Flags().BoolP(FlagDryRun, "d", false, "dry run")
+	appManagementApplyCmd.Flags().Bool(FlagAppManagementNoPortCheck, false, "skip checking published ports for conflicts")
 
 	appManagementApplyCmd.Flags().StringP(FlagFile, "f", "", "path to a compose file")
 	if err := appManagementApplyCmd.MarkFlagRequired(FlagFile); err != nil {
@@ -0,0 +1,226 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/spf13/cobra"
+)
+
+const FlagAppManagementOutputFile = "output-file"
+
+// appManagementBundleExportCmd represents the appManagementBundleExport command
+var appManagementBundleExportCmd = &cobra.Command{
+	Use:   "export <appid|store-app-id>",
+	Short: "export an app with its images to a bundle file",
+	Long: `Export an app to a bundle file, to install it on a host without internet access with "bundle import".
+
+The bundle is a tar file with the compose file of the app, including its store info in x-casaos, the images of every
+service as saved by docker save, and a manifest with their digests. A locally installed app is exported as it is
+installed, otherwise the app is looked up in registered app stores.
+
+Images are saved from the Docker engine of the CasaOS host - missing ones are pulled first.`,
+	Example: `  casaos-cli app-management bundle export jellyfin -o jellyfin.tar`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename, err := cmd.Flags().GetString(FlagAppManagementOutputFile)
+		if err != nil {
+			return err
+		}
+
+		appID := args[0]
+
+		if filename == "" {
+			filename = appID + ".tar"
+		}
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		source := BundleSourceLocal
+
+		composeYAML, err := client.Apps().Compose(ctx, appID)
+		if code, _ := exitCode(err); code == ExitCodeNotFound {
+			source = BundleSourceStore
+			composeYAML, err = client.Store().Compose(ctx, appID)
+		}
+		if err != nil {
+			return err
+		}
+
+		project, err := parseComposeProject(appID+".yaml", composeYAML, os.TempDir(), func(o *loader.Options) {
+			o.SkipInterpolation = true
+		})
+		if err != nil {
+			return err
+		}
+
+		docker, err := newDockerEngine()
+		if err != nil {
+			return err
+		}
+
+		// saving images may take long, so no timeout
+		imageCtx := context.Background()
+
+		manifest := bundleManifest{
+			Version: bundleVersion,
+			AppID:   appID,
+			Source:  source,
+			Created: time.Now().UTC(),
+			Files:   map[string]string{},
+		}
+
+		images := projectImages(project)
+
+		for _, image := range images {
+			id, err := docker.ImageID(imageCtx, image)
+			if err != nil {
+				return err
+			}
+
+			if id == "" {
+				log.Printf("pulling %s", image)
+
+				if err := docker.Pull(imageCtx, image); err != nil {
+					return fmt.Errorf("failed to pull %s - %w", image, err)
+				}
+
+				if id, err = docker.ImageID(imageCtx, image); err != nil {
+					return err
+				}
+			}
+
+			manifest.Images = append(manifest.Images, bundleImage{Name: image, ID: id})
+		}
+
+		log.Printf("saving %d image(s)", len(images))
+
+		imagesFile, err := os.CreateTemp("", "casaos-bundle-*")
+		if err != nil {
+			return err
+		}
+		defer removeTemp(imagesFile)
+
+		imagesDigest := newDigestWriter()
+
+		if err := docker.Save(imageCtx, images, io.MultiWriter(imagesFile, imagesDigest)); err != nil {
+			return fmt.Errorf("failed to save images - %w", err)
+		}
+
+		composeDigest := newDigestWriter()
+		composeDigest.Write(composeYAML)
+
+		manifest.Files[BundleComposeFile] = composeDigest.Digest()
+		manifest.Files[BundleImagesFile] = imagesDigest.Digest()
+
+		if err := writeBundleFile(filename, &manifest, composeYAML, imagesFile); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "exported %s app %s with %d image(s) to %s\n", source, appID, len(images), filename)
+
+		return nil
+	},
+}
+
+func init() {
+	appManagementBundleCmd.AddCommand(appManagementBundleExportCmd)
+
+	appManagementBundleExportCmd.Flags().StringP(FlagAppManagementOutputFile, "o", "", "path of the bundle file to write (default to <appid>.tar in current directory)")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementBundleExportCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementBundleExportCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// writeBundleFile writes the manifest, compose file and saved images to a bundle file. The bundle is written to a
+// temporary file first, so a failed export does not leave a broken bundle behind.
+func writeBundleFile(filename string, manifest *bundleManifest, composeYAML []byte, imagesFile *os.File) error {
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	stat, err := imagesFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := imagesFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w := tar.NewWriter(f)
+
+	if err := writeBundleEntry(w, BundleManifestFile, int64(len(manifestJSON)), bytes.NewReader(manifestJSON)); err != nil {
+		return err
+	}
+
+	if err := writeBundleEntry(w, BundleComposeFile, int64(len(composeYAML)), bytes.NewReader(composeYAML)); err != nil {
+		return err
+	}
+
+	if err := writeBundleEntry(w, BundleImagesFile, stat.Size(), imagesFile); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
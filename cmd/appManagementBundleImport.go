@@ -0,0 +1,186 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/spf13/cobra"
+)
+
+const FlagAppManagementNoInstall = "no-install"
+
+// appManagementBundleImportCmd represents the appManagementBundleImport command
+var appManagementBundleImportCmd = &cobra.Command{
+	Use:   "import <bundle-file>",
+	Short: "import an app with its images from a bundle file",
+	Long: `Import an app from a bundle file made by "bundle export": verify the digests of the compose file and images in
+it against its manifest, load the images into the Docker engine of the CasaOS host, verify the image IDs, and install
+the app.
+
+Use --no-install to only load the images.`,
+	Example: `  casaos-cli app-management bundle import jellyfin.tar`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noInstall, err := cmd.Flags().GetBool(FlagAppManagementNoInstall)
+		if err != nil {
+			return err
+		}
+
+		dryRun, err := cmd.Flags().GetBool(FlagDryRun)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r := tar.NewReader(f)
+
+		manifest, err := readBundleManifest(r)
+		if err != nil {
+			return err
+		}
+
+		var composeYAML []byte
+		var imagesFile *os.File
+
+		digests := map[string]string{}
+
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("invalid bundle - %w", err)
+			}
+
+			switch header.Name {
+			case BundleComposeFile:
+				if composeYAML, err = io.ReadAll(r); err != nil {
+					return err
+				}
+
+				digest := newDigestWriter()
+				digest.Write(composeYAML)
+				digests[header.Name] = digest.Digest()
+
+			case BundleImagesFile:
+				if imagesFile != nil {
+					return fmt.Errorf("invalid bundle - %s is found more than once", header.Name)
+				}
+
+				if imagesFile, digests[header.Name], err = spoolBundleEntry(r); err != nil {
+					return err
+				}
+				defer removeTemp(imagesFile)
+
+			default:
+				log.Printf("skipping unknown file %s in bundle", header.Name)
+			}
+		}
+
+		for _, name := range []string{BundleComposeFile, BundleImagesFile} {
+			expected, ok := manifest.Files[name]
+			if !ok {
+				return fmt.Errorf("invalid bundle - digest of %s is not in %s", name, BundleManifestFile)
+			}
+
+			if digests[name] == "" {
+				return fmt.Errorf("invalid bundle - %s is not found", name)
+			}
+
+			if digests[name] != expected {
+				return fmt.Errorf("digest of %s is %s, but %s in %s - the bundle is corrupted or modified", name, digests[name], expected, BundleManifestFile)
+			}
+		}
+
+		docker, err := newDockerEngine()
+		if err != nil {
+			return err
+		}
+
+		// loading images may take long, so no timeout
+		imageCtx := context.Background()
+
+		log.Printf("loading %d image(s)", len(manifest.Images))
+
+		if err := docker.Load(imageCtx, imagesFile); err != nil {
+			return fmt.Errorf("failed to load images - %w", err)
+		}
+
+		for _, image := range manifest.Images {
+			id, err := docker.ImageID(imageCtx, image.Name)
+			if err != nil {
+				return err
+			}
+
+			if id != image.ID {
+				return fmt.Errorf("image %s has ID %s after loading, but %s in %s", image.Name, id, image.ID, BundleManifestFile)
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "loaded %d image(s) of app %s\n", len(manifest.Images), manifest.AppID)
+
+		if noInstall {
+			return nil
+		}
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		message, err := client.Apps().Install(ctx, composeYAML, casaos.InstallOptions{DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+
+		log.Println(message)
+
+		return nil
+	},
+}
+
+func init() {
+	appManagementBundleCmd.AddCommand(appManagementBundleImportCmd)
+
+	appManagementBundleImportCmd.Flags().Bool(FlagAppManagementNoInstall, false, "only load the images, without installing the app")
+	appManagementBundleImportCmd.Flags().BoolP(FlagDryRun, "d", false, "dry run of installing the app, after loading the images")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementBundleImportCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementBundleImportCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
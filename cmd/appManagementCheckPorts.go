@@ -0,0 +1,644 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FlagAppManagementWrite       = "write"
+	FlagAppManagementNoPortCheck = "no-port-check"
+	FlagAppManagementApp         = "app"
+
+	maxPort = 65535
+)
+
+// appManagementCheckPortsCmd represents the appManagementCheckPorts command
+var appManagementCheckPortsCmd = &cobra.Command{
+	Use:     "check-ports",
+	Short:   "check published ports of a compose file against ports in use",
+	Aliases: []string{"check-port"},
+	Long: `Check every port published by the services in a compose file against the TCP/UDP ports
+currently in use on the host and the ports used by other installed compose apps.
+
+For each conflicting port a free alternative is suggested. With --write, the compose file
+is rewritten in place with the suggested ports.
+
+When the compose file is a change to an installed app, use --app to name it, so that ports
+it already publishes are not reported as conflicts.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filepath := cmd.Flag(FlagFile).Value.String()
+
+		write, err := cmd.Flags().GetBool(FlagAppManagementWrite)
+		if err != nil {
+			return err
+		}
+
+		appID, err := cmd.Flags().GetString(FlagAppManagementApp)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		project, err := loadComposeProject(filepath)
+		if err != nil {
+			return err
+		}
+
+		conflicts, err := checkComposePorts(ctx, appID, project)
+		if err != nil {
+			return err
+		}
+
+		if len(conflicts) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "no port conflict found")
+			return nil
+		}
+
+		printPortConflicts(cmd.OutOrStdout(), conflicts)
+
+		if !write {
+			return nil
+		}
+
+		if err := rewriteComposePorts(filepath, conflicts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%s updated with suggested ports\n", filepath)
+
+		return nil
+	},
+}
+
+func init() {
+	appManagementCmd.AddCommand(appManagementCheckPortsCmd)
+
+	appManagementCheckPortsCmd.Flags().StringP(FlagFile, "f", "", "path to a compose file")
+	if err := appManagementCheckPortsCmd.MarkFlagRequired(FlagFile); err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	appManagementCheckPortsCmd.Flags().BoolP(FlagAppManagementWrite, "w", false, "rewrite the compose file with suggested ports")
+	appManagementCheckPortsCmd.Flags().String(FlagAppManagementApp, "", "id of the installed app the compose file is for, whose own ports are not conflicts")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementCheckPortsCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementCheckPortsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+type publishedPort struct {
+	Service  string
+	Port     int
+	Protocol string
+}
+
+type portConflict struct {
+	publishedPort
+
+	Reason    string
+	Suggested int
+}
+
+func loadComposeProject(path string) (*types.Project, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	workingDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseComposeProject(path, buf, workingDir)
+}
+
+func parseComposeProject(filename string, buf []byte, workingDir string, options ...func(*loader.Options)) (*types.Project, error) {
+	return loader.Load(types.ConfigDetails{
+		WorkingDir: workingDir,
+		ConfigFiles: []types.ConfigFile{
+			{Filename: filename, Content: buf},
+		},
+		Environment: map[string]string{},
+	}, options...)
+}
+
+// publishedPorts returns every host port published by the services of the project, with port ranges expanded.
+func publishedPorts(project *types.Project) []publishedPort {
+	ports := []publishedPort{}
+
+	for _, service := range project.Services {
+		for _, port := range service.Ports {
+			protocol := strings.ToLower(port.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+
+			for _, p := range parsePortRange(port.Published) {
+				ports = append(ports, publishedPort{Service: service.Name, Port: p, Protocol: protocol})
+			}
+		}
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Port < ports[j].Port
+	})
+
+	return ports
+}
+
+func parsePortRange(published string) []int {
+	if published == "" {
+		return nil
+	}
+
+	start, end, found := strings.Cut(published, "-")
+
+	from, err := strconv.Atoi(start)
+	if err != nil {
+		return nil
+	}
+
+	if !found {
+		return []int{from}
+	}
+
+	to, err := strconv.Atoi(end)
+	if err != nil || to < from {
+		return []int{from}
+	}
+
+	ports := make([]int, 0, to-from+1)
+	for p := from; p <= to; p++ {
+		ports = append(ports, p)
+	}
+
+	return ports
+}
+
+// checkComposePorts compares published ports of the project against ports in use on the host and
+// ports published by other installed compose apps, and suggests a free port for each conflict. Ports
+// published by the installed app with the given id, if any, are not conflicts.
+func checkComposePorts(ctx context.Context, appID string, project *types.Project) ([]portConflict, error) {
+	tcpInUse, udpInUse, err := portsInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appPorts, err := installedAppPorts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ports already published by this very app (e.g. when applying changes) are not conflicts
+	ownPorts := map[string]bool{}
+	if appID != "" {
+		for _, p := range appPorts[appID] {
+			ownPorts[portKey(p.Port, p.Protocol)] = true
+		}
+	}
+
+	usedByApp := map[string]string{}
+	for id, ports := range appPorts {
+		if id == appID {
+			continue
+		}
+
+		for _, p := range ports {
+			usedByApp[portKey(p.Port, p.Protocol)] = id
+		}
+	}
+
+	taken := func(port int, protocol string) bool {
+		key := portKey(port, protocol)
+		if _, ok := usedByApp[key]; ok {
+			return true
+		}
+
+		if ownPorts[key] {
+			return false
+		}
+
+		if protocol == "udp" {
+			return udpInUse[port]
+		}
+
+		return tcpInUse[port]
+	}
+
+	ports := publishedPorts(project)
+
+	// ports requested by the project itself must not be suggested for another conflict
+	reserved := map[string]bool{}
+	for _, p := range ports {
+		reserved[portKey(p.Port, p.Protocol)] = true
+	}
+
+	conflicts := []portConflict{}
+
+	for _, p := range ports {
+		key := portKey(p.Port, p.Protocol)
+
+		reason := ""
+		if appID, ok := usedByApp[key]; ok {
+			reason = fmt.Sprintf("used by app %s", appID)
+		} else if taken(p.Port, p.Protocol) {
+			reason = "in use"
+		}
+
+		if reason == "" {
+			continue
+		}
+
+		suggested := 0
+		for candidate := p.Port + 1; candidate <= maxPort; candidate++ {
+			if reserved[portKey(candidate, p.Protocol)] || taken(candidate, p.Protocol) {
+				continue
+			}
+
+			suggested = candidate
+			reserved[portKey(candidate, p.Protocol)] = true
+			break
+		}
+
+		conflicts = append(conflicts, portConflict{publishedPort: p, Reason: reason, Suggested: suggested})
+	}
+
+	return conflicts, nil
+}
+
+func portKey(port int, protocol string) string {
+	return fmt.Sprintf("%d/%s", port, protocol)
+}
+
+func portsInUse(ctx context.Context) (map[int]bool, map[int]bool, error) {
+	client, err := casaosClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ports, err := client.Health().Ports(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tcp, udp := map[int]bool{}, map[int]bool{}
+
+	for _, port := range ports.TCP {
+		tcp[port] = true
+	}
+
+	for _, port := range ports.UDP {
+		udp[port] = true
+	}
+
+	return tcp, udp, nil
+}
+
+// installedAppPorts returns ports published by each installed compose app, including its `port_map`.
+func installedAppPorts(ctx context.Context) (map[string][]publishedPort, error) {
+	client, err := casaosClient()
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := client.Apps().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string][]publishedPort{}
+
+	for _, app := range apps {
+		ports := []publishedPort{}
+
+		for _, service := range app.Services {
+			for _, port := range service.Ports {
+				for _, p := range parsePortRange(port.Published) {
+					ports = append(ports, publishedPort{Service: service.Name, Port: p, Protocol: port.Protocol})
+				}
+			}
+		}
+
+		if p, err := strconv.Atoi(app.PortMap); err == nil {
+			ports = append(ports, publishedPort{Port: p, Protocol: "tcp"})
+		}
+
+		result[app.ID] = ports
+	}
+
+	return result, nil
+}
+
+func printPortConflicts(writer io.Writer, conflicts []portConflict) {
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "SERVICE\tPORT\tTYPE\tREASON\tSUGGESTED")
+	fmt.Fprintln(w, "-------\t----\t----\t------\t---------")
+
+	for _, conflict := range conflicts {
+		suggested := "n/a"
+		if conflict.Suggested > 0 {
+			suggested = strconv.Itoa(conflict.Suggested)
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+			conflict.Service,
+			conflict.Port,
+			strings.ToUpper(conflict.Protocol),
+			conflict.Reason,
+			suggested,
+		)
+	}
+}
+
+// rewriteComposePorts replaces each conflicting published port in the compose file with its suggested
+// port, including `x-casaos.port_map` if it points to one of them. The file is edited as a YAML node
+// tree, so key order and comments are preserved.
+func rewriteComposePorts(path string, conflicts []portConflict) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(buf, &document); err != nil {
+		return err
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return fmt.Errorf("%s is not a compose file", path)
+	}
+
+	// suggested ports by service, then by port and protocol
+	replacements := map[string]map[string]int{}
+	for _, conflict := range conflicts {
+		if conflict.Suggested == 0 {
+			continue
+		}
+
+		if _, ok := replacements[conflict.Service]; !ok {
+			replacements[conflict.Service] = map[string]int{}
+		}
+
+		replacements[conflict.Service][portKey(conflict.Port, conflict.Protocol)] = conflict.Suggested
+	}
+
+	compose := document.Content[0]
+
+	if services := mappingValue(compose, "services"); services != nil && services.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(services.Content); i += 2 {
+			if r, ok := replacements[services.Content[i].Value]; ok {
+				rewriteServicePorts(services.Content[i+1], r)
+			}
+		}
+	}
+
+	if portMap := mappingValue(mappingValue(compose, ComposeExtensionCasaOS), "port_map"); portMap != nil {
+		if port, err := strconv.Atoi(portMap.Value); err == nil {
+			for _, r := range replacements {
+				if newPort, ok := r[portKey(port, "tcp")]; ok {
+					portMap.Value = strconv.Itoa(newPort)
+					break
+				}
+			}
+		}
+	}
+
+	var output bytes.Buffer
+
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, output.Bytes(), 0o644)
+}
+
+// mappingValue returns the value node of the key in a mapping node, or nil if there is no such key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func rewriteServicePorts(service *yaml.Node, replacements map[string]int) {
+	ports := mappingValue(service, "ports")
+	if ports == nil || ports.Kind != yaml.SequenceNode {
+		return
+	}
+
+	content := make([]*yaml.Node, 0, len(ports.Content))
+
+	for _, port := range ports.Content {
+		switch port.Kind {
+		case yaml.ScalarNode:
+			content = append(content, rewriteShortPortSyntax(port, replacements)...)
+
+		case yaml.MappingNode:
+			published := mappingValue(port, "published")
+			if published == nil {
+				continue
+			}
+
+			protocol := "tcp"
+			if p := mappingValue(port, "protocol"); p != nil && p.Value != "" {
+				protocol = strings.ToLower(p.Value)
+			}
+
+			if mapped := remapPorts(published.Value, "", protocol, replacements); mapped != nil {
+				published.Value = mapped[0][0]
+			}
+
+			content = append(content, port)
+
+		default:
+			content = append(content, port)
+		}
+	}
+
+	ports.Content = content
+}
+
+// rewriteShortPortSyntax rewrites the published part of `[HOST_IP:]PUBLISHED:TARGET[/PROTOCOL]`. It returns
+// more than one node when a port range has to be split.
+func rewriteShortPortSyntax(port *yaml.Node, replacements map[string]int) []*yaml.Node {
+	spec, protocol, hasProtocol := strings.Cut(port.Value, "/")
+
+	// a bare container port is published on a random host port - nothing to rewrite
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 {
+		return []*yaml.Node{port}
+	}
+
+	published, target := len(parts)-2, len(parts)-1
+
+	mapped := remapPorts(parts[published], parts[target], lo.If(hasProtocol, strings.ToLower(protocol)).Else("tcp"), replacements)
+	if mapped == nil {
+		return []*yaml.Node{port}
+	}
+
+	nodes := make([]*yaml.Node, 0, len(mapped))
+
+	for i, m := range mapped {
+		parts[published], parts[target] = m[0], m[1]
+
+		value := strings.Join(parts, ":")
+		if hasProtocol {
+			value += "/" + protocol
+		}
+
+		node := *port
+		node.Value = value
+
+		// keep comments around the original entry when it is split
+		if i > 0 {
+			node.HeadComment = ""
+		}
+
+		if i < len(mapped)-1 {
+			node.LineComment, node.FootComment = "", ""
+		}
+
+		nodes = append(nodes, &node)
+	}
+
+	return nodes
+}
+
+// remapPorts replaces conflicting published ports with suggested ones, and returns the resulting pairs of
+// published and target ports, or nil if nothing changes. A published range mapped to a target range of the
+// same size is split into single ports, while a published range mapped to a single target port, i.e. any free
+// port in the range, is narrowed to its longest run of ports without conflict.
+func remapPorts(published, target, protocol string, replacements map[string]int) [][2]string {
+	ports := parsePortRange(published)
+
+	conflicting := lo.Filter(ports, func(p int, _ int) bool {
+		_, ok := replacements[portKey(p, protocol)]
+		return ok
+	})
+
+	if len(conflicting) == 0 {
+		return nil
+	}
+
+	if len(ports) == 1 {
+		return [][2]string{{strconv.Itoa(replacements[portKey(ports[0], protocol)]), target}}
+	}
+
+	if targets := parsePortRange(target); len(targets) == len(ports) {
+		mapped := make([][2]string, 0, len(ports))
+
+		for i, p := range ports {
+			if newPort, ok := replacements[portKey(p, protocol)]; ok {
+				p = newPort
+			}
+
+			mapped = append(mapped, [2]string{strconv.Itoa(p), strconv.Itoa(targets[i])})
+		}
+
+		return mapped
+	}
+
+	from, to := 0, -1
+	for start := 0; start < len(ports); start++ {
+		end := start
+		for end < len(ports) && !lo.Contains(conflicting, ports[end]) {
+			end++
+		}
+
+		if end-start > to-from+1 {
+			from, to = start, end-1
+		}
+
+		start = end
+	}
+
+	switch {
+	case to < from:
+		// no free port left in the range
+		return [][2]string{{strconv.Itoa(replacements[portKey(conflicting[0], protocol)]), target}}
+	case to == from:
+		return [][2]string{{strconv.Itoa(ports[from]), target}}
+	default:
+		return [][2]string{{fmt.Sprintf("%d-%d", ports[from], ports[to]), target}}
+	}
+}
+
+// preflightPortCheck is run before submitting a compose file to install, or apply to the installed app
+// with the given id. It fails if any published port conflicts, but only warns if the check itself cannot
+// be performed.
+func preflightPortCheck(ctx context.Context, writer io.Writer, appID, path string) error {
+	project, err := loadComposeProject(path)
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := checkComposePorts(ctx, appID, project)
+	if err != nil {
+		log.Printf("skipping port check: %s", err.Error())
+		return nil
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	printPortConflicts(writer, conflicts)
+
+	return conflictError{err: fmt.Errorf("port conflict found - run `check-ports -f %s --write` to use suggested ports, or use --%s to skip this check", path, FlagAppManagementNoPortCheck)}
+}
@@ -17,16 +17,14 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/alecthomas/chroma/quick"
-	"github.com/samber/lo"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
 // appManagementConvertAppFileCmd represents the appManagementConvertAppFile command
@@ -34,11 +32,6 @@ var appManagementConvertAppFileCmd = &cobra.Command{
 	Use:   "appfile",
 	Short: "convert `appfile.json` to Docker Compose YAML (for local conversion, use `appfile2compose` command)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
 		filepath := cmd.Flag(FlagFile).Value.String()
 
 		useColor, err := cmd.Flags().GetBool(FlagAppManagementUseColor)
@@ -46,9 +39,7 @@ var appManagementConvertAppFileCmd = &cobra.Command{
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -56,33 +47,26 @@ var appManagementConvertAppFileCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		file, err := os.Open(filepath)
+		appFileJSON, err := os.ReadFile(filepath)
 		if err != nil {
 			return err
 		}
 
-		params := app_management.ConvertParams{Type: lo.ToPtr(app_management.Appfile)}
-
-		response, err := client.ConvertWithBodyWithResponse(ctx, &params, MINEApplicationJSON, file)
+		composeYAML, err := client.Apps().ConvertAppFile(ctx, appFileJSON)
 		if err != nil {
-			fmt.Println("Error: Unable to reach CasaOS API. Try convert locally using `appfile2compose` command.")
-			return err
-		}
-
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := yaml.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+			var statusErr *casaos.StatusError
+			if !errors.As(err, &statusErr) {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Error: Unable to reach CasaOS API. Try convert locally using `appfile2compose` command.")
 			}
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
+			return err
 		}
 
 		if useColor {
-			return quick.Highlight(cmd.OutOrStdout(), string(response.Body), "yaml", "terminal8", "native")
+			return quick.Highlight(cmd.OutOrStdout(), string(composeYAML), "yaml", "terminal8", "native")
 		}
 
-		fmt.Println(string(response.Body))
+		fmt.Fprintln(cmd.OutOrStdout(), string(composeYAML))
 
 		return nil
 	},
@@ -0,0 +1,706 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/chroma/quick"
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// flags of `docker run` that have no equivalent in a compose file, with the reason to ignore them
+var dockerRunIgnoredFlags = map[string]string{
+	"attach":                "containers of apps run detached",
+	"cidfile":               "container ids of apps are managed by CasaOS",
+	"detach-keys":           "containers of apps run detached",
+	"disable-content-trust": "images are pulled by CasaOS",
+	"label-file":            "put labels in the command with --label instead",
+	"publish-all":           "publish each port with --publish instead",
+	"quiet":                 "images are pulled by CasaOS",
+	"rm":                    "containers of apps are kept until the app is uninstalled",
+	"sig-proxy":             "containers of apps run detached",
+}
+
+// characters not allowed in compose project and service names
+var composeNameInvalidPattern = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// network modes of `docker run --network` that are not a user-defined network
+var dockerRunNetworkModePattern = regexp.MustCompile(`^(host|none|bridge|default|(container|service):.+)$`)
+
+// dockerRunOptions are flags of `docker run`.
+type dockerRunOptions struct {
+	Name, Hostname, Domainname, User, Workdir, Entrypoint, Restart, Network, IP, IP6 string
+	Pid, Ipc, Uts, Userns, Platform, Pull, StopSignal, MacAddress, CgroupParent      string
+	Runtime, Isolation, VolumeDriver, LogDriver, CPUSet, GPUs                        string
+	Memory, MemoryReservation, MemorySwap, ShmSize                                   string
+
+	HealthCmd                                                         string
+	HealthInterval, HealthTimeout, HealthStartPeriod                  time.Duration
+	HealthRetries, StopTimeout                                        int
+	CPUs                                                              float64
+	CPUShares, CPUPeriod, CPUQuota, PidsLimit                         int64
+	OomScoreAdj, MemorySwappiness                                     int64
+	Interactive, TTY, Privileged, ReadOnly, Init                      bool
+	OomKillDisable, NoHealthcheck                                     bool
+	Publish, Expose, Volume, Mount, Tmpfs, Env, EnvFile               []string
+	Label, CapAdd, CapDrop, Device, DeviceCgroupRule                  []string
+	DNS, DNSSearch, DNSOption, AddHost, SecurityOpt                   []string
+	Sysctl, Ulimit, LogOpt, GroupAdd, Link, NetworkAlias, VolumesFrom []string
+}
+
+// appManagementConvertDockerRunCmd represents the appManagementConvertDockerRun command
+var appManagementConvertDockerRunCmd = &cobra.Command{
+	Use:   "docker-run -- <docker run command>",
+	Short: "convert a `docker run` command to Docker Compose YAML for CasaOS",
+	Long: `Convert a docker run command to Docker Compose YAML, with a skeleton of store info in x-casaos, i.e. main
+service, title, icon, architectures and port_map, so it can be installed with "install -f".
+
+Put the command after --, either as separate arguments or quoted as one, e.g. copied from a README. Flags that have
+no equivalent in a compose file, like --rm, are ignored with a warning. Replace the placeholder icon and fill in
+the rest of x-casaos before publishing the app.`,
+	Example: `  casaos-cli app-management convert docker-run -- docker run -d -p 8080:80 -v /data:/data --name foo image:tag
+  casaos-cli app-management convert docker-run -- "docker run -d -p 8080:80 nginx" > docker-compose.yml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		useColor, err := cmd.Flags().GetBool(FlagAppManagementUseColor)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 && strings.ContainsAny(args[0], " \t\n") {
+			if args, err = shellSplit(args[0]); err != nil {
+				return usageError{err: err}
+			}
+		}
+
+		project, err := dockerRunProject(args)
+		if err != nil {
+			return err
+		}
+
+		composeYAML, err := yaml.Marshal(project)
+		if err != nil {
+			return err
+		}
+
+		if useColor {
+			return quick.Highlight(cmd.OutOrStdout(), string(composeYAML), "yaml", "terminal8", "native")
+		}
+
+		_, err = cmd.OutOrStdout().Write(composeYAML)
+		return err
+	},
+}
+
+func init() {
+	appManagementConvertCmd.AddCommand(appManagementConvertDockerRunCmd)
+
+	appManagementConvertDockerRunCmd.Flags().BoolP(FlagAppManagementUseColor, "c", false, "colorize output")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementConvertDockerRunCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementConvertDockerRunCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+func newDockerRunFlagSet(o *dockerRunOptions) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("docker run", pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+
+	for name, p := range map[string]*string{
+		"name": &o.Name, "domainname": &o.Domainname, "entrypoint": &o.Entrypoint, "restart": &o.Restart, "ip": &o.IP,
+		"ip6": &o.IP6, "pid": &o.Pid, "ipc": &o.Ipc, "uts": &o.Uts, "userns": &o.Userns, "platform": &o.Platform,
+		"pull": &o.Pull, "stop-signal": &o.StopSignal, "mac-address": &o.MacAddress, "cgroup-parent": &o.CgroupParent,
+		"runtime": &o.Runtime, "isolation": &o.Isolation, "volume-driver": &o.VolumeDriver, "log-driver": &o.LogDriver,
+		"cpuset-cpus": &o.CPUSet, "gpus": &o.GPUs, "memory-reservation": &o.MemoryReservation,
+		"memory-swap": &o.MemorySwap, "shm-size": &o.ShmSize, "health-cmd": &o.HealthCmd,
+		"cidfile": new(string), "detach-keys": new(string),
+	} {
+		flags.StringVar(p, name, "", "")
+	}
+
+	flags.StringVarP(&o.Hostname, "hostname", "h", "", "")
+	flags.StringVarP(&o.User, "user", "u", "", "")
+	flags.StringVarP(&o.Workdir, "workdir", "w", "", "")
+	flags.StringVarP(&o.Memory, "memory", "m", "", "")
+	flags.StringVar(&o.Network, "network", "", "")
+	flags.StringVar(&o.Network, "net", "", "")
+
+	flags.DurationVar(&o.HealthInterval, "health-interval", 0, "")
+	flags.DurationVar(&o.HealthTimeout, "health-timeout", 0, "")
+	flags.DurationVar(&o.HealthStartPeriod, "health-start-period", 0, "")
+	flags.IntVar(&o.HealthRetries, "health-retries", 0, "")
+	flags.IntVar(&o.StopTimeout, "stop-timeout", 0, "")
+	flags.Float64Var(&o.CPUs, "cpus", 0, "")
+	flags.Int64VarP(&o.CPUShares, "cpu-shares", "c", 0, "")
+	flags.Int64Var(&o.CPUPeriod, "cpu-period", 0, "")
+	flags.Int64Var(&o.CPUQuota, "cpu-quota", 0, "")
+	flags.Int64Var(&o.PidsLimit, "pids-limit", 0, "")
+	flags.Int64Var(&o.OomScoreAdj, "oom-score-adj", 0, "")
+	flags.Int64Var(&o.MemorySwappiness, "memory-swappiness", -1, "")
+
+	flags.BoolVarP(&o.Interactive, "interactive", "i", false, "")
+	flags.BoolVarP(&o.TTY, "tty", "t", false, "")
+	flags.BoolVar(&o.Privileged, "privileged", false, "")
+	flags.BoolVar(&o.ReadOnly, "read-only", false, "")
+	flags.BoolVar(&o.Init, "init", false, "")
+	flags.BoolVar(&o.OomKillDisable, "oom-kill-disable", false, "")
+	flags.BoolVar(&o.NoHealthcheck, "no-healthcheck", false, "")
+	flags.BoolP("detach", "d", false, "")
+	flags.BoolP("publish-all", "P", false, "")
+	flags.BoolP("quiet", "q", false, "")
+	flags.Bool("rm", false, "")
+	flags.Bool("sig-proxy", true, "")
+	flags.Bool("disable-content-trust", true, "")
+
+	for name, p := range map[string]*[]string{
+		"expose": &o.Expose, "mount": &o.Mount, "tmpfs": &o.Tmpfs, "env-file": &o.EnvFile, "cap-add": &o.CapAdd,
+		"cap-drop": &o.CapDrop, "device": &o.Device, "device-cgroup-rule": &o.DeviceCgroupRule, "dns": &o.DNS,
+		"dns-search": &o.DNSSearch, "dns-option": &o.DNSOption, "dns-opt": &o.DNSOption, "add-host": &o.AddHost,
+		"security-opt": &o.SecurityOpt, "sysctl": &o.Sysctl, "ulimit": &o.Ulimit, "log-opt": &o.LogOpt,
+		"group-add": &o.GroupAdd, "link": &o.Link, "network-alias": &o.NetworkAlias, "volumes-from": &o.VolumesFrom,
+		"label-file": new([]string),
+	} {
+		flags.StringArrayVar(p, name, nil, "")
+	}
+
+	flags.StringArrayVarP(&o.Publish, "publish", "p", nil, "")
+	flags.StringArrayVarP(&o.Volume, "volume", "v", nil, "")
+	flags.StringArrayVarP(&o.Env, "env", "e", nil, "")
+	flags.StringArrayVarP(&o.Label, "label", "l", nil, "")
+	flags.StringArrayP("attach", "a", nil, "")
+
+	return flags
+}
+
+// dockerRunProject converts a `docker run` command, with or without `docker run` itself, to a compose project with
+// a skeleton of store info.
+func dockerRunProject(args []string) (*types.Project, error) {
+	for _, word := range []string{"docker", "container", "run"} {
+		if len(args) > 0 && args[0] == word {
+			args = args[1:]
+		}
+	}
+
+	var o dockerRunOptions
+
+	flags := newDockerRunFlagSet(&o)
+	if err := flags.Parse(args); err != nil {
+		return nil, usageError{err: fmt.Errorf("invalid docker run command - %w", err)}
+	}
+
+	if flags.NArg() == 0 {
+		return nil, usageError{err: fmt.Errorf("image is not found in docker run command")}
+	}
+
+	flags.Visit(func(flag *pflag.Flag) {
+		if reason, ok := dockerRunIgnoredFlags[flag.Name]; ok {
+			log.Printf("ignoring --%s - %s", flag.Name, reason)
+		}
+	})
+
+	image := flags.Arg(0)
+
+	name := o.Name
+	if name == "" {
+		name = imageRepository(image)
+	}
+	name = composeName(name)
+
+	project := &types.Project{
+		Name:       name,
+		Networks:   types.Networks{},
+		Volumes:    types.Volumes{},
+		Extensions: types.Extensions{},
+	}
+
+	service, err := o.service(name, image, flags.Args()[1:], project)
+	if err != nil {
+		return nil, err
+	}
+
+	project.Services = types.Services{*service}
+	project.Extensions[ComposeExtensionCasaOS] = dockerRunStoreInfo(service, o.Platform)
+
+	return project, nil
+}
+
+func (o *dockerRunOptions) service(name, image string, command []string, project *types.Project) (*types.ServiceConfig, error) {
+	service := &types.ServiceConfig{
+		Name:              name,
+		Image:             image,
+		ContainerName:     o.Name,
+		Hostname:          o.Hostname,
+		DomainName:        o.Domainname,
+		User:              o.User,
+		WorkingDir:        o.Workdir,
+		Restart:           o.Restart,
+		Pid:               o.Pid,
+		Ipc:               o.Ipc,
+		Uts:               o.Uts,
+		UserNSMode:        o.Userns,
+		Platform:          o.Platform,
+		PullPolicy:        o.Pull,
+		StopSignal:        o.StopSignal,
+		MacAddress:        o.MacAddress,
+		CgroupParent:      o.CgroupParent,
+		Runtime:           o.Runtime,
+		Isolation:         o.Isolation,
+		VolumeDriver:      o.VolumeDriver,
+		CPUSet:            o.CPUSet,
+		CPUS:              float32(o.CPUs),
+		CPUShares:         o.CPUShares,
+		CPUPeriod:         o.CPUPeriod,
+		CPUQuota:          o.CPUQuota,
+		OomScoreAdj:       o.OomScoreAdj,
+		StdinOpen:         o.Interactive,
+		Tty:               o.TTY,
+		Privileged:        o.Privileged,
+		ReadOnly:          o.ReadOnly,
+		OomKillDisable:    o.OomKillDisable,
+		CapAdd:            o.CapAdd,
+		CapDrop:           o.CapDrop,
+		Devices:           o.Device,
+		DeviceCgroupRules: o.DeviceCgroupRule,
+		DNS:               o.DNS,
+		DNSSearch:         o.DNSSearch,
+		DNSOpts:           o.DNSOption,
+		ExtraHosts:        dockerRunHosts(o.AddHost),
+		SecurityOpt:       o.SecurityOpt,
+		GroupAdd:          o.GroupAdd,
+		Links:             o.Link,
+		VolumesFrom:       o.VolumesFrom,
+		Tmpfs:             o.Tmpfs,
+		EnvFile:           o.EnvFile,
+		Expose:            o.Expose,
+		Environment:       types.MappingWithEquals{},
+		Labels:            dockerRunMapping(o.Label),
+		Sysctls:           dockerRunMapping(o.Sysctl),
+	}
+
+	if len(command) > 0 {
+		service.Command = command
+	}
+
+	if o.Entrypoint != "" {
+		service.Entrypoint = types.ShellCommand{o.Entrypoint}
+	}
+
+	if o.Init {
+		service.Init = &o.Init
+	}
+
+	if o.PidsLimit != 0 {
+		service.PidsLimit = o.PidsLimit
+	}
+
+	if o.MemorySwappiness >= 0 {
+		service.MemSwappiness = types.UnitBytes(o.MemorySwappiness)
+	}
+
+	if o.StopTimeout != 0 {
+		service.StopGracePeriod = durationPtr(time.Duration(o.StopTimeout) * time.Second)
+	}
+
+	for flag, value := range map[string]struct {
+		raw  string
+		dest *types.UnitBytes
+	}{
+		"memory":             {o.Memory, &service.MemLimit},
+		"memory-reservation": {o.MemoryReservation, &service.MemReservation},
+		"memory-swap":        {o.MemorySwap, &service.MemSwapLimit},
+		"shm-size":           {o.ShmSize, &service.ShmSize},
+	} {
+		if value.raw == "" {
+			continue
+		}
+
+		if value.raw == "-1" {
+			*value.dest = -1
+			continue
+		}
+
+		size, err := units.RAMInBytes(value.raw)
+		if err != nil {
+			return nil, usageError{err: fmt.Errorf("invalid --%s %s - %w", flag, value.raw, err)}
+		}
+
+		*value.dest = types.UnitBytes(size)
+	}
+
+	for _, env := range o.Env {
+		key, value, found := strings.Cut(env, "=")
+		if found {
+			service.Environment[key] = &value
+		} else {
+			service.Environment[key] = nil
+		}
+	}
+
+	for _, publish := range o.Publish {
+		ports, err := types.ParsePortConfig(publish)
+		if err != nil {
+			return nil, usageError{err: fmt.Errorf("invalid --publish %s - %w", publish, err)}
+		}
+
+		service.Ports = append(service.Ports, ports...)
+	}
+
+	for _, volume := range o.Volume {
+		config, err := loader.ParseVolume(volume)
+		if err != nil {
+			return nil, usageError{err: fmt.Errorf("invalid --volume %s - %w", volume, err)}
+		}
+
+		service.Volumes = append(service.Volumes, config)
+	}
+
+	for _, mount := range o.Mount {
+		config, err := parseDockerMount(mount)
+		if err != nil {
+			return nil, usageError{err: fmt.Errorf("invalid --mount %s - %w", mount, err)}
+		}
+
+		service.Volumes = append(service.Volumes, config)
+	}
+
+	// named volumes are declared with their names, to keep using the volumes created by `docker run`
+	for _, volume := range service.Volumes {
+		if volume.Type == types.VolumeTypeVolume && volume.Source != "" {
+			project.Volumes[volume.Source] = types.VolumeConfig{Name: volume.Source}
+		}
+	}
+
+	for _, ulimit := range o.Ulimit {
+		if service.Ulimits == nil {
+			service.Ulimits = map[string]*types.UlimitsConfig{}
+		}
+
+		name, config, err := parseDockerUlimit(ulimit)
+		if err != nil {
+			return nil, usageError{err: fmt.Errorf("invalid --ulimit %s - %w", ulimit, err)}
+		}
+
+		service.Ulimits[name] = config
+	}
+
+	if o.LogDriver != "" || len(o.LogOpt) > 0 {
+		service.Logging = &types.LoggingConfig{Driver: o.LogDriver, Options: dockerRunMapping(o.LogOpt)}
+	}
+
+	if o.NoHealthcheck {
+		service.HealthCheck = &types.HealthCheckConfig{Disable: true}
+	} else if o.HealthCmd != "" {
+		service.HealthCheck = &types.HealthCheckConfig{Test: types.HealthCheckTest{"CMD-SHELL", o.HealthCmd}}
+
+		if o.HealthInterval > 0 {
+			service.HealthCheck.Interval = durationPtr(o.HealthInterval)
+		}
+
+		if o.HealthTimeout > 0 {
+			service.HealthCheck.Timeout = durationPtr(o.HealthTimeout)
+		}
+
+		if o.HealthStartPeriod > 0 {
+			service.HealthCheck.StartPeriod = durationPtr(o.HealthStartPeriod)
+		}
+
+		if o.HealthRetries > 0 {
+			retries := uint64(o.HealthRetries)
+			service.HealthCheck.Retries = &retries
+		}
+	}
+
+	if o.GPUs != "" {
+		request, err := parseDockerGPUs(o.GPUs)
+		if err != nil {
+			return nil, usageError{err: fmt.Errorf("invalid --gpus %s - %w", o.GPUs, err)}
+		}
+
+		service.Deploy = &types.DeployConfig{
+			Resources: types.Resources{
+				Reservations: &types.Resource{Devices: []types.DeviceRequest{request}},
+			},
+		}
+	}
+
+	switch {
+	case o.Network == "":
+	case dockerRunNetworkModePattern.MatchString(o.Network):
+		service.NetworkMode = o.Network
+	default:
+		// a user-defined network, which must exist already as with `docker run`
+		service.Networks = map[string]*types.ServiceNetworkConfig{
+			o.Network: {Aliases: o.NetworkAlias, Ipv4Address: o.IP, Ipv6Address: o.IP6},
+		}
+
+		project.Networks[o.Network] = types.NetworkConfig{Name: o.Network, External: types.External{External: true}}
+	}
+
+	if service.Networks == nil && (len(o.NetworkAlias) > 0 || o.IP != "" || o.IP6 != "") {
+		log.Printf("ignoring --network-alias, --ip and --ip6 - they only work with a user-defined --network")
+	}
+
+	return service, nil
+}
+
+// dockerRunStoreInfo returns a skeleton of store info for the service converted from `docker run`.
+func dockerRunStoreInfo(service *types.ServiceConfig, platform string) map[string]interface{} {
+	architectures := []string{"amd64", "arm64"}
+	if platform != "" {
+		architecture := strings.Split(strings.TrimPrefix(platform, "linux/"), "/")[0]
+		architectures = []string{architecture}
+	}
+
+	storeInfo := map[string]interface{}{
+		"main":          service.Name,
+		"architectures": architectures,
+		"author":        "self",
+		"icon":          fmt.Sprintf("%s/%s/%s/icon.png", DefaultAppStoreAssetsURL, StoreAppsDir, service.Name),
+		"title": map[string]string{
+			DefaultLanguage: storeAppTitle(service.Name),
+		},
+	}
+
+	for _, port := range service.Ports {
+		if port.Published != "" && (port.Protocol == "" || port.Protocol == "tcp") {
+			storeInfo["port_map"] = port.Published
+			storeInfo["scheme"] = "http"
+			storeInfo["index"] = "/"
+			break
+		}
+	}
+
+	return storeInfo
+}
+
+// imageRepository returns the last part of the repository of an image, e.g. `jellyfin` of `linuxserver/jellyfin:10.8`.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	image = strings.TrimSuffix(image, ":"+imageTag(image))
+
+	return path.Base(image)
+}
+
+// composeName turns a container or image name into a valid compose project and service name.
+func composeName(name string) string {
+	name = strings.ToLower(name)
+	name = composeNameInvalidPattern.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-_")
+
+	if name == "" {
+		return "app"
+	}
+
+	return name
+}
+
+// dockerRunMapping turns `key=value` items of flags like --label into a mapping, with empty values for `key` only.
+func dockerRunMapping(items []string) map[string]string {
+	if len(items) == 0 {
+		return nil
+	}
+
+	mapping := map[string]string{}
+	for _, item := range items {
+		key, value, _ := strings.Cut(item, "=")
+		mapping[key] = value
+	}
+
+	return mapping
+}
+
+// dockerRunHosts turns items of --add-host like `host:ip` or `host=ip` into host-ip mappings.
+func dockerRunHosts(items []string) types.HostsList {
+	if len(items) == 0 {
+		return nil
+	}
+
+	hosts := types.HostsList{}
+	for _, item := range items {
+		// IPv6 addresses contain colons, so only the first separator counts
+		host, ip, found := strings.Cut(item, "=")
+		if !found {
+			host, ip, _ = strings.Cut(item, ":")
+		}
+
+		hosts[host] = ip
+	}
+
+	return hosts
+}
+
+// parseDockerMount parses --mount like `type=bind,source=/data,target=/data,readonly`.
+func parseDockerMount(mount string) (types.ServiceVolumeConfig, error) {
+	config := types.ServiceVolumeConfig{Type: types.VolumeTypeVolume}
+
+	for _, field := range strings.Split(mount, ",") {
+		key, value, _ := strings.Cut(field, "=")
+
+		switch strings.ToLower(key) {
+		case "type":
+			config.Type = value
+		case "source", "src":
+			config.Source = value
+		case "target", "destination", "dst":
+			config.Target = value
+		case "readonly", "ro":
+			config.ReadOnly = value == "" || value == "true" || value == "1"
+		case "bind-propagation":
+			config.Bind = &types.ServiceVolumeBind{Propagation: value}
+		case "volume-nocopy":
+			config.Volume = &types.ServiceVolumeVolume{NoCopy: value == "" || value == "true" || value == "1"}
+		case "tmpfs-size":
+			size, err := units.RAMInBytes(value)
+			if err != nil {
+				return config, err
+			}
+			config.Tmpfs = &types.ServiceVolumeTmpfs{Size: types.UnitBytes(size)}
+		default:
+			return config, fmt.Errorf("unsupported option %s", key)
+		}
+	}
+
+	if config.Target == "" {
+		return config, fmt.Errorf("target is not set")
+	}
+
+	return config, nil
+}
+
+// parseDockerUlimit parses --ulimit like `nofile=1024:2048` or `nproc=65535`.
+func parseDockerUlimit(ulimit string) (string, *types.UlimitsConfig, error) {
+	name, limits, found := strings.Cut(ulimit, "=")
+	if !found {
+		return "", nil, fmt.Errorf("should be <type>=<soft limit>[:<hard limit>]")
+	}
+
+	soft, hard, found := strings.Cut(limits, ":")
+
+	softLimit, err := strconv.Atoi(soft)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !found {
+		return name, &types.UlimitsConfig{Single: softLimit}, nil
+	}
+
+	hardLimit, err := strconv.Atoi(hard)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, &types.UlimitsConfig{Soft: softLimit, Hard: hardLimit}, nil
+}
+
+// parseDockerGPUs parses --gpus like `all`, `2` or `device=0,1` into an NVIDIA device request.
+func parseDockerGPUs(gpus string) (types.DeviceRequest, error) {
+	request := types.DeviceRequest{Driver: "nvidia", Capabilities: []string{"gpu"}}
+
+	gpus = strings.Trim(gpus, `"'`)
+
+	switch {
+	case gpus == "all":
+		request.Count = -1
+	case strings.HasPrefix(gpus, "device="):
+		request.IDs = strings.Split(strings.TrimPrefix(gpus, "device="), ",")
+	default:
+		count, err := strconv.ParseInt(gpus, 10, 64)
+		if err != nil {
+			return request, fmt.Errorf("should be all, a number or device=<ids>")
+		}
+		request.Count = count
+	}
+
+	return request, nil
+}
+
+func durationPtr(d time.Duration) *types.Duration {
+	duration := types.Duration(d)
+	return &duration
+}
+
+// shellSplit splits a shell command into words, with quotes, backslash escapes and line continuations, e.g. a
+// `docker run` command copied from a README.
+func shellSplit(command string) ([]string, error) {
+	words := []string{}
+
+	var word strings.Builder
+	inWord, quote, escaped := false, rune(0), false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			escaped = false
+			if r == '\n' {
+				continue
+			}
+			word.WriteRune(r)
+			inWord = true
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in command", quote)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
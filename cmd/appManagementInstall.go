@@ -17,13 +17,10 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"net/http"
 	"os"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
-	"github.com/samber/lo"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/spf13/cobra"
 )
 
@@ -33,47 +30,40 @@ var appManagementInstallCmd = &cobra.Command{
 	Aliases: []string{"add", "create", "up"},
 	Short:   "install a compose app",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
 		dryRun := cmd.Flag(FlagDryRun).Value.String() == "true"
 
 		filepath := cmd.Flag(FlagFile).Value.String()
 
-		file, err := os.Open(filepath)
+		noPortCheck, err := cmd.Flags().GetBool(FlagAppManagementNoPortCheck)
 		if err != nil {
 			return err
 		}
 
-		client, err := app_management.NewClientWithResponses(url)
+		composeYAML, err := os.ReadFile(filepath)
 		if err != nil {
 			return err
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		params := app_management.InstallComposeAppParams{DryRun: lo.ToPtr(dryRun)}
-
-		response, err := client.InstallComposeAppWithBodyWithResponse(ctx, &params, MIMEApplicationYAML, file)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		if !noPortCheck {
+			if err := preflightPortCheck(ctx, cmd.OutOrStdout(), "", filepath); err != nil {
+				return err
 			}
+		}
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
+		message, err := client.Apps().Install(ctx, composeYAML, casaos.InstallOptions{DryRun: dryRun})
+		if err != nil {
+			return err
 		}
 
-		log.Println(*response.JSON200.Message)
+		log.Println(message)
 
 		return nil
 	},
@@ -83,6 +73,7 @@ func init() {
 	appManagementCmd.AddCommand(appManagementInstallCmd)
 
 	appManagementInstallCmd.Flags().BoolP(FlagDryRun, "d", false, "dry run")
+	appManagementInstallCmd.Flags().Bool(FlagAppManagementNoPortCheck, false, "skip checking published ports for conflicts")
 
 	appManagementInstallCmd.Flags().StringP(FlagFile, "f", "", "path to a compose file")
 	if err := appManagementInstallCmd.MarkFlagRequired(FlagFile); err != nil {
@@ -18,10 +18,8 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"text/tabwriter"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -31,14 +29,7 @@ var appManagementListAppStoresCmd = &cobra.Command{
 	Short:   "list registered app stores",
 	Aliases: []string{"app-store", "appstore", "appstores"},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -46,28 +37,19 @@ var appManagementListAppStoresCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.AppStoreListWithResponse(ctx)
+		appStores, err := client.Store().Stores(ctx)
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
 		defer w.Flush()
 
 		fmt.Fprintln(w, "ID\tURL\tSTORE ROOT")
 		fmt.Fprintln(w, "--\t---\t----------")
 
-		for id, appStore := range *response.JSON200.Data {
-			fmt.Fprintf(w, "%d\t%s\t%s\n", id, *appStore.URL, *appStore.StoreRoot)
+		for _, appStore := range appStores {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", appStore.ID, appStore.URL, appStore.StoreRoot)
 		}
 
 		return nil
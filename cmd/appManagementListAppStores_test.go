@@ -0,0 +1,26 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "testing"
+
+func TestAppManagementListAppStores(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "app-management", "list", "app-stores")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "app_management_list_app_stores", stdout)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/spf13/cobra"
+)
+
+// appManagementListCategoriesCmd represents the appManagementListCategories command
+var appManagementListCategoriesCmd = &cobra.Command{
+	Use:     "categories",
+	Short:   "list categories of apps in app store, with number of apps in each",
+	Aliases: []string{"category"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		apps, err := client.Store().Apps(ctx, casaos.StoreAppFilter{})
+		if err != nil {
+			return err
+		}
+
+		counts := map[string]int{}
+		for _, app := range apps {
+			category := app.Category
+			if category == "" {
+				category = "-"
+			}
+			counts[category]++
+		}
+
+		categories := make([]string, 0, len(counts))
+		for category := range counts {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "CATEGORY\tAPPS")
+		fmt.Fprintln(w, "--------\t----")
+
+		for _, category := range categories {
+			fmt.Fprintf(w, "%s\t%d\n", category, counts[category])
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	appManagementListCmd.AddCommand(appManagementListCategoriesCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementListCategoriesCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementListCategoriesCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -18,9 +18,7 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -34,11 +32,6 @@ var appManagementLogsCmd = &cobra.Command{
 	Short: "retrieve logs of a compose app",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
 		lines, err := cmd.Flags().GetInt(FlagAppManagementLogsLines)
 		if err != nil {
 			return err
@@ -48,9 +41,7 @@ var appManagementLogsCmd = &cobra.Command{
 			return fmt.Errorf("lines must be greater than 0")
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -59,23 +50,14 @@ var appManagementLogsCmd = &cobra.Command{
 		defer cancel()
 
 		appID := cmd.Flags().Arg(0)
-		response, err := client.ComposeAppLogsWithResponse(ctx, appID, &app_management.ComposeAppLogsParams{Lines: &lines})
+		logs, err := client.Apps().Logs(ctx, appID, lines)
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
-		fmt.Printf("(showing last %d lines)\n", lines)
-		fmt.Println("...")
-		fmt.Println(*response.JSON200.Data)
+		fmt.Fprintf(cmd.OutOrStdout(), "(showing last %d lines)\n", lines)
+		fmt.Fprintln(cmd.OutOrStdout(), "...")
+		fmt.Fprintln(cmd.OutOrStdout(), logs)
 
 		return nil
 	},
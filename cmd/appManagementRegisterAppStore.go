@@ -18,9 +18,7 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -31,14 +29,7 @@ var appManagementRegisterAppStoreCmd = &cobra.Command{
 	Aliases: []string{"appstore"},
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -47,26 +38,12 @@ var appManagementRegisterAppStoreCmd = &cobra.Command{
 		defer cancel()
 
 		appStoreURL := cmd.Flags().Arg(0)
-		response, err := client.RegisterAppStoreWithResponse(ctx, &app_management.RegisterAppStoreParams{Url: &appStoreURL})
+		message, err := client.Store().Register(ctx, appStoreURL)
 		if err != nil {
 			return err
 		}
 
-		var baseResponse app_management.BaseResponse
-
-		if response.StatusCode() != http.StatusOK {
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
-		if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-			return fmt.Errorf("%s - %s", response.Status(), response.Body)
-		}
-
-		fmt.Println(*baseResponse.Message)
+		fmt.Fprintln(cmd.OutOrStdout(), message)
 
 		return nil
 	},
@@ -17,11 +17,8 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"net/http"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -30,16 +27,9 @@ var appManagementRestartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "restart a compose app",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
 		appID := cmd.Flags().Arg(0)
 
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -47,27 +37,17 @@ var appManagementRestartCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		response, err := client.SetComposeAppStatusWithResponse(ctx, appID, app_management.SetComposeAppStatusJSONRequestBody(app_management.SetComposeAppStatusJSONBodyRestart))
+		message, err := client.Apps().Restart(ctx, appID)
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
-		if response.JSON200 == nil || response.JSON200.Message == nil {
+		if message == "" {
 			log.Println("compose app restarted successfully - no message is returned")
 			return nil
 		}
 
-		log.Println(*response.JSON200.Message)
-
+		log.Println(message)
 		return nil
 	},
 }
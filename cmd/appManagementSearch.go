@@ -18,11 +18,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"sort"
 	"strings"
 	"text/tabwriter"
+	"unicode"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
@@ -34,97 +35,151 @@ const (
 )
 
 var authorTypes = []string{
-	string(app_management.Official), string(app_management.ByCasaos), string(app_management.Community),
+	casaos.AuthorTypeOfficial, casaos.AuthorTypeByCasaOS, casaos.AuthorTypeCommunity,
+}
+
+const (
+	FlagAppManagementSort         = "sort"
+	FlagAppManagementInstalled    = "installed"
+	FlagAppManagementNotInstalled = "not-installed"
+	FlagAppManagementLimit        = "limit"
+
+	SortByRelevance  = "relevance"
+	SortByName       = "name"
+	SortByCategory   = "category"
+	SortByPopularity = "popularity"
+)
+
+var searchSortOrders = []string{SortByRelevance, SortByName, SortByCategory, SortByPopularity}
+
+// scoredStoreApp is a store app with its score against the search query.
+type scoredStoreApp struct {
+	casaos.StoreApp
+
+	Score int
 }
 
 // appManagementSearchCmd represents the appManagementSearch command
 var appManagementSearchCmd = &cobra.Command{
-	Use:   "search",
+	Use:   "search [query...]",
 	Short: "search for apps in app store",
+	Long: `Search for apps in app store. Each word of the query is matched against IDs, titles, descriptions and tags
+in all languages, tolerating typos, and only apps matching all words are shown, the most relevant first.
+
+Without a query, all apps are shown sorted by name. Use --sort to order by name, category or popularity,
+which puts apps recommended by the app stores first, in their recommended order.`,
+	Example: `  casaos-cli app-management search jelly media
+  casaos-cli app-management search --category Media --not-installed --sort popularity --limit 10`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+		filter := casaos.StoreAppFilter{}
+
+		category, err := cmd.Flags().GetString(FlagAppManagementCategory)
 		if err != nil {
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
+		filter.Category = category
 
-		client, err := app_management.NewClientWithResponses(url)
+		authorType, err := cmd.Flags().GetString(FlagAppManagementAuthorType)
 		if err != nil {
 			return err
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		if authorType != "" && !lo.Contains(authorTypes, authorType) {
+			return usageError{err: fmt.Errorf("invalid author type %s, should be one of %s", authorType, strings.Join(authorTypes, ", "))}
+		}
 
-		params := &app_management.ComposeAppStoreInfoListParams{}
+		filter.AuthorType = authorType
 
-		category, err := cmd.Flags().GetString(FlagAppManagementCategory)
+		filter.Recommend, err = cmd.Flags().GetBool(FlagAppManagementRecommend)
 		if err != nil {
 			return err
 		}
 
-		if category != "" {
-			params.Category = &category
+		sortOrder, err := cmd.Flags().GetString(FlagAppManagementSort)
+		if err != nil {
+			return err
 		}
 
-		authorType, err := cmd.Flags().GetString(FlagAppManagementAuthorType)
+		if sortOrder == "" {
+			sortOrder = lo.If(len(args) > 0, SortByRelevance).Else(SortByName)
+		}
+
+		if !lo.Contains(searchSortOrders, sortOrder) {
+			return usageError{err: fmt.Errorf("invalid sort order %s, should be one of %s", sortOrder, strings.Join(searchSortOrders, ", "))}
+		}
+
+		installed, err := cmd.Flags().GetBool(FlagAppManagementInstalled)
 		if err != nil {
 			return err
 		}
 
-		if authorType != "" {
-			if !lo.Contains(authorTypes, authorType) {
-				return fmt.Errorf("invalid author type %s, should be one of %s", authorType, strings.Join(authorTypes, ", "))
-			}
-
-			params.AuthorType = (*app_management.StoreAppAuthorType)(&authorType)
+		notInstalled, err := cmd.Flags().GetBool(FlagAppManagementNotInstalled)
+		if err != nil {
+			return err
 		}
 
-		recommend, err := cmd.Flags().GetBool(FlagAppManagementRecommend)
+		limit, err := cmd.Flags().GetInt(FlagAppManagementLimit)
 		if err != nil {
 			return err
 		}
 
-		if recommend {
-			params.Recommend = &recommend
+		client, err := casaosClient()
+		if err != nil {
+			return err
 		}
 
-		response, err := client.ComposeAppStoreInfoListWithResponse(ctx, params)
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		apps, err := client.Store().Apps(ctx, filter)
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+		results := []scoredStoreApp{}
+
+		for _, app := range apps {
+			if (installed && !app.Installed) || (notInstalled && app.Installed) {
+				continue
 			}
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
+			score := searchScore(app, args)
+			if score == 0 {
+				continue
+			}
 
-		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
-		defer w.Flush()
+			results = append(results, scoredStoreApp{StoreApp: app, Score: score})
+		}
 
-		if response.JSON200.Data.List == nil || len(*response.JSON200.Data.List) == 0 {
+		if len(results) == 0 {
 			return fmt.Errorf("no compose app found from this store")
 		}
 
-		installedList := []string{}
-		if response.JSON200.Data.Installed != nil && len(*response.JSON200.Data.Installed) != 0 {
-			installedList = *response.JSON200.Data.Installed
+		sortSearchResults(results, sortOrder)
+
+		if limit > 0 && len(results) > limit {
+			results = results[:limit]
 		}
 
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
 		fmt.Fprintln(w, "Name\tCategory\tAuthor\tDeveloper\tDescription")
 		fmt.Fprintln(w, "----\t--------\t------\t---------\t-----------")
 
-		for storeAppID, composeApp := range *response.JSON200.Data.List {
-			if lo.Contains(installedList, storeAppID) {
+		for _, app := range results {
+			storeAppID := app.ID
+			if app.Installed {
 				storeAppID = fmt.Sprintf("%s [installed]", storeAppID)
 			}
 
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", storeAppID, composeApp.Category, composeApp.Author, composeApp.Developer, trim(composeApp.Description[DefaultLanguage], 78))
+			description := casaos.Localized(app.Tagline, DefaultLanguage)
+			if description == "" {
+				description = casaos.Localized(app.Description, DefaultLanguage)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", storeAppID, app.Category, app.Author, app.Developer, trim(firstLine(description), 78))
 		}
 
 		return nil
@@ -140,6 +195,13 @@ func init() {
 
 	appManagementSearchCmd.Flags().BoolP(FlagAppManagementRecommend, "r", false, "recommend apps")
 
+	appManagementSearchCmd.Flags().StringP(FlagAppManagementSort, "s", "", fmt.Sprintf("sort order of results (%s) - relevance if a query is given, otherwise name", strings.Join(searchSortOrders, ", ")))
+	appManagementSearchCmd.Flags().Bool(FlagAppManagementInstalled, false, "only show installed apps")
+	appManagementSearchCmd.Flags().Bool(FlagAppManagementNotInstalled, false, "only show apps not installed")
+	appManagementSearchCmd.Flags().IntP(FlagAppManagementLimit, "n", 0, "show at most this many results (0 for all)")
+
+	appManagementSearchCmd.MarkFlagsMutuallyExclusive(FlagAppManagementInstalled, FlagAppManagementNotInstalled)
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -150,3 +212,145 @@ func init() {
 	// is called directly, e.g.:
 	// appManagementSearchCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// searchScore scores how well the app matches every word of the query, or 0 if any word does not match.
+// Without a query every app matches.
+func searchScore(app casaos.StoreApp, query []string) int {
+	id := strings.ToLower(app.ID)
+	titles := lowerValues(lo.Values(app.Title))
+	descriptions := lowerValues(append(lo.Values(app.Description), lo.Values(app.Tagline)...))
+	tags := lowerValues(app.Tags)
+
+	total := 1
+
+	for _, word := range query {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+
+		score := lo.Max([]int{
+			matchScore(word, []string{id}, 100),
+			matchScore(word, titles, 80),
+			matchScore(word, tags, 60),
+			matchScore(word, descriptions, 20),
+		})
+
+		if score == 0 {
+			return 0
+		}
+
+		total += score
+	}
+
+	return total
+}
+
+// matchScore scores a word against values, from an exact match scored as weight down to a typo in a word of them.
+func matchScore(word string, values []string, weight int) int {
+	best := 0
+
+	for _, value := range values {
+		switch {
+		case value == word:
+			best = lo.Max([]int{best, weight})
+		case strings.HasPrefix(value, word):
+			best = lo.Max([]int{best, weight * 3 / 4})
+		case strings.Contains(value, word):
+			best = lo.Max([]int{best, weight / 2})
+		default:
+			for _, w := range strings.FieldsFunc(value, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+				if fuzzyMatch(word, w) {
+					best = lo.Max([]int{best, weight / 4})
+					break
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+// fuzzyMatch tells whether the word matches the target with a typo, i.e. within an edit distance of 1 for
+// short words, or 2 for words of 8 characters or more. Words shorter than 4 characters must match exactly.
+func fuzzyMatch(word, target string) bool {
+	a, b := []rune(word), []rune(target)
+
+	if len(a) < 4 {
+		return false
+	}
+
+	// also match a prefix of the target with a typo, e.g. `jelyfin` for `jellyfin`
+	if len(b) > len(a)+1 {
+		b = b[:len(a)+1]
+	}
+
+	maxDistance := 1
+	if len(a) >= 8 {
+		maxDistance = 2
+	}
+
+	return editDistance(a, b) <= maxDistance
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = lo.Min([]int{previous[j] + 1, current[j-1] + 1, previous[j-1] + cost})
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func sortSearchResults(results []scoredStoreApp, sortOrder string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		switch sortOrder {
+		case SortByRelevance:
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+		case SortByCategory:
+			if a.Category != b.Category {
+				return strings.ToLower(a.Category) < strings.ToLower(b.Category)
+			}
+		case SortByPopularity:
+			if a.Recommended != b.Recommended {
+				return a.Recommended
+			}
+
+			if a.RecommendRank != b.RecommendRank {
+				return a.RecommendRank < b.RecommendRank
+			}
+
+			if a.Installed != b.Installed {
+				return a.Installed
+			}
+		}
+
+		return a.ID < b.ID
+	})
+}
+
+func lowerValues(values []string) []string {
+	return lo.Map(values, func(value string, _ int) string { return strings.ToLower(value) })
+}
@@ -18,10 +18,8 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"text/tabwriter"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -56,14 +54,7 @@ OPENAI_API_KEY   sk-xxxxxxxxxx
 		return nil
 	}),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -72,24 +63,11 @@ OPENAI_API_KEY   sk-xxxxxxxxxx
 		defer cancel()
 
 		key := args[0]
-		body := app_management.GlobalSetting{
-			Value: args[1],
-		}
-		response, err := client.UpdateGlobalSettingWithResponse(ctx, key, body)
+		setting, err := client.Apps().SetGlobalSetting(ctx, key, args[1])
 		if err != nil {
 			return err
 		}
 
-		var baseResponse app_management.BaseResponse
-
-		if response.StatusCode() != http.StatusOK {
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
 		defer w.Flush()
 
@@ -97,8 +75,8 @@ OPENAI_API_KEY   sk-xxxxxxxxxx
 		fmt.Fprintln(w, "--------------\t------------")
 
 		fmt.Fprintf(w, "%s\t%s\t\n",
-			*response.JSON200.Data.Key,
-			response.JSON200.Data.Value,
+			setting.Key,
+			setting.Value,
 		)
 
 		return nil
@@ -18,10 +18,8 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"text/tabwriter"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -30,14 +28,7 @@ var appManagementShowGlobalCmd = &cobra.Command{
 	Use:  "global <Key>",
 	Args: cobra.ExactArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -45,31 +36,21 @@ var appManagementShowGlobalCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.GetGlobalSettingsWithResponse(ctx)
+		settings, err := client.Apps().GlobalSettings(ctx)
 		if err != nil {
 			return err
 		}
 
-		var baseResponse app_management.GetGlobalSettingsResponse
-
-		if response.StatusCode() != http.StatusOK {
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), baseResponse.Body)
-		}
-
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
 		defer w.Flush()
 
 		fmt.Fprintln(w, "Global Key\tGlobal Value")
 		fmt.Fprintln(w, "--------------\t------------")
 
-		for _, value := range *response.JSON200.Data {
+		for _, value := range settings {
 
 			fmt.Fprintf(w, "%s\t%s\t\n",
-				*value.Key,
+				value.Key,
 				value.Value,
 			)
 		}
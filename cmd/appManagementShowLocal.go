@@ -16,64 +16,89 @@ limitations under the License.
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"net/http"
+	"regexp"
+	"sort"
 	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/alecthomas/chroma/quick"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
 
+// words in names of environment variables whose values are masked - only whole words count, so that e.g.
+// `KEYBOARD_LAYOUT` or `AUTHOR` are shown as is
+var secretWords = []string{
+	"PASS", "PASSWD", "PASSWORD", "PASSPHRASE", "PWD",
+	"SECRET", "SECRETS", "TOKEN", "KEY", "APIKEY",
+	"CREDENTIAL", "CREDENTIALS", "AUTH",
+}
+
+// camelCaseBoundary splits words in names like `dbPassword`
+var camelCaseBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
 // appManagementShowLocalCmd represents the appManagementShowLocal command
 var appManagementShowLocalCmd = &cobra.Command{
 	Use:   "local <appid>",
 	Short: "show information of a locally installed app",
 	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
+	Long: `Show a summary of a locally installed app: title, version, author, category, web UI, published ports,
+volumes, environment variables and containers. Values of environment variables that look like secrets are masked.
 
+Use --yaml to show the compose file of the app as it is.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		useYAML, err := cmd.Flags().GetBool(FlagAppManagementYAML)
 		if err != nil {
 			return err
 		}
 
-		useColor, err := cmd.Flags().GetBool(FlagAppManagementUseColor)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
 		appID := cmd.Flags().Arg(0)
 
-		client, err := app_management.NewClientWithResponses(url)
-		if err != nil {
-			return err
-		}
-
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
 		if useYAML {
+			useColor, err := cmd.Flags().GetBool(FlagAppManagementUseColor)
+			if err != nil {
+				return err
+			}
+
+			client, err := casaosClient()
+			if err != nil {
+				return err
+			}
+
 			return showYAML(ctx, cmd.OutOrStdout(), client, appID, useColor)
 		}
 
-		if err := showAppList(ctx, cmd.OutOrStdout(), client, appID, useColor); err != nil {
+		language, err := cmd.Flags().GetString(FlagAppManagementLanguage)
+		if err != nil {
+			return err
+		}
+
+		client, err := casaosClient()
+		if err != nil {
 			return err
 		}
 
-		if err := showContainers(ctx, cmd.OutOrStdout(), client, appID); err != nil {
+		app, err := client.Apps().Get(ctx, appID)
+		if err != nil {
+			return err
+		}
+
+		containers, err := client.Apps().Containers(ctx, appID)
+		if err != nil {
 			return err
 		}
 
+		showAppSummary(cmd.OutOrStdout(), app, language)
+		showContainers(cmd.OutOrStdout(), containers)
+
 		return nil
 	},
 }
@@ -83,6 +108,7 @@ func init() {
 
 	appManagementShowLocalCmd.Flags().BoolP(FlagAppManagementYAML, "", false, "output in raw YAML format")
 	appManagementShowLocalCmd.Flags().BoolP(FlagAppManagementUseColor, "c", false, "colorize output")
+	appManagementShowLocalCmd.Flags().String(FlagAppManagementLanguage, DefaultLanguage, "language of title, description and tips, e.g. zh_cn")
 
 	// Here you will define your flags and configuration settings.
 
@@ -95,30 +121,18 @@ func init() {
 	// appManagementShowLocalCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func showYAML(ctx context.Context, writer io.Writer, client *app_management.ClientWithResponses, appID string, useColor bool) error {
-	response, err := client.MyComposeAppWithResponse(ctx, appID, func(ctx context.Context, req *http.Request) error {
-		req.Header.Set("Accept", "application/yaml")
-		return nil
-	})
+func showYAML(ctx context.Context, writer io.Writer, client *casaos.Client, appID string, useColor bool) error {
+	composeYAML, err := client.Apps().Compose(ctx, appID)
 	if err != nil {
 		return err
 	}
 
-	if response.StatusCode() != http.StatusOK {
-		var baseResponse app_management.BaseResponse
-		if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-			return fmt.Errorf("%s - %s", response.Status(), response.Body)
-		}
-
-		return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-	}
-
 	if useColor {
-		if err := quick.Highlight(writer, string(response.Body), "yaml", "terminal8", "native"); err != nil {
+		if err := quick.Highlight(writer, string(composeYAML), "yaml", "terminal8", "native"); err != nil {
 			return err
 		}
 	} else {
-		if _, err := io.WriteString(writer, string(response.Body)); err != nil {
+		if _, err := io.WriteString(writer, string(composeYAML)); err != nil {
 			return err
 		}
 	}
@@ -126,132 +140,171 @@ func showYAML(ctx context.Context, writer io.Writer, client *app_management.Clie
 	return nil
 }
 
-func showContainers(ctx context.Context, writer io.Writer, client *app_management.ClientWithResponses, appID string) error {
-	response, err := client.ComposeAppContainersWithResponse(ctx, appID)
-	if err != nil {
-		return err
+// showAppSummary prints store info and services of the app, with title, description and tips in the given language.
+func showAppSummary(writer io.Writer, app *casaos.AppDetail, language string) {
+	title := casaos.Localized(app.Title, language)
+	if title == "" {
+		title = app.ID
 	}
 
-	if response.StatusCode() != http.StatusOK {
-		var baseResponse app_management.BaseResponse
-		if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-			return fmt.Errorf("%s - %s", response.Status(), response.Body)
+	heading := fmt.Sprintf("%s (%s)", title, app.ID)
+	fmt.Fprintln(writer, heading)
+	fmt.Fprintln(writer, strings.Repeat("=", utf8.RuneCountInString(heading)))
+
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintf(w, "Status:\t%s\n", valueOrDash(app.Status))
+
+	for _, service := range app.Services {
+		if service.Name == app.Main || len(app.Services) == 1 {
+			fmt.Fprintf(w, "Version:\t%s (%s)\n", imageTag(service.Image), service.Image)
 		}
+	}
 
-		return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
+	fmt.Fprintf(w, "Author:\t%s\n", valueOrDash(app.Author))
+	fmt.Fprintf(w, "Developer:\t%s\n", valueOrDash(app.Developer))
+	fmt.Fprintf(w, "Category:\t%s\n", valueOrDash(app.Category))
+
+	if app.CasaOSApp {
+		fmt.Fprintf(w, "Web UI:\t%s\n", app.WebUI)
 	}
 
-	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
-	defer w.Flush()
+	w.Flush()
 
-	fmt.Fprintln(w, "CONTAINER NAME\tCONTAINER ID\tIMAGE\tSTATE")
-	fmt.Fprintln(w, "--------------\t------------\t-----\t-----")
+	if description := casaos.Localized(app.Description, language); description != "" {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Description:")
+		fmt.Fprintln(writer, indent(description, "  "))
+	}
 
-	mainApp := *response.JSON200.Data.Main
-	for id, container := range *response.JSON200.Data.Containers {
+	if tips := casaos.Localized(app.Tips, language); tips != "" {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Tips:")
+		fmt.Fprintln(writer, indent(tips, "  "))
+	}
 
-		name := container.Name
-		if id == mainApp {
-			name = fmt.Sprintf("%s (main)", name)
-		}
+	fmt.Fprintln(writer)
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-			name,
-			container.ID,
-			container.Image,
-			container.State,
-		)
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tPUBLISHED\tTARGET\tPROTOCOL")
+	fmt.Fprintln(w, "-------\t---------\t------\t--------")
+	for _, service := range app.Services {
+		for _, port := range service.Ports {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service.Name, valueOrDash(port.Published), port.Target, port.Protocol)
+		}
 	}
+	w.Flush()
 
-	return nil
-}
+	fmt.Fprintln(writer)
 
-func showAppList(ctx context.Context, writer io.Writer, client *app_management.ClientWithResponses, appID string, useColor bool) error {
-	response, err := client.MyComposeApp(ctx, appID)
-	if err != nil {
-		return err
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tTYPE\tSOURCE\tTARGET")
+	fmt.Fprintln(w, "-------\t----\t------\t------")
+	for _, service := range app.Services {
+		for _, volume := range service.Volumes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service.Name, valueOrDash(volume.Type), valueOrDash(volume.Source), volume.Target)
+		}
 	}
-	defer response.Body.Close()
+	w.Flush()
 
-	buf, err := io.ReadAll(response.Body)
-	if err != nil {
-		return err
+	fmt.Fprintln(writer)
+
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tENVIRONMENT VARIABLE\tVALUE")
+	fmt.Fprintln(w, "-------\t--------------------\t-----")
+	for _, service := range app.Services {
+		keys := lo.Keys(service.Environment)
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", service.Name, key, maskSecret(key, service.Environment[key]))
+		}
 	}
+	w.Flush()
 
-	if response.StatusCode != http.StatusOK {
-		var baseResponse app_management.BaseResponse
-		if err := json.Unmarshal(buf, &baseResponse); err != nil {
-			body, err := io.ReadAll(response.Body)
-			if err != nil {
-				return err
-			}
+	fmt.Fprintln(writer)
+}
 
-			message := string(body)
-			if message == "" {
-				message = "is the casaos-app-management service running?"
-			}
+func showContainers(writer io.Writer, containers []casaos.Container) {
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	defer w.Flush()
 
-			return fmt.Errorf("%s - %s", response.Status, message)
+	fmt.Fprintln(w, "CONTAINER NAME\tCONTAINER ID\tIMAGE\tSTATE\tHEALTH\tUPTIME")
+	fmt.Fprintln(w, "--------------\t------------\t-----\t-----\t------\t------")
+
+	for _, container := range containers {
+		name := container.Name
+		if container.Main {
+			name = fmt.Sprintf("%s (main)", name)
 		}
 
-		return fmt.Errorf("%s - %s", response.Status, *baseResponse.Message)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			name,
+			lo.Substring(container.ID, 0, 12),
+			container.Image,
+			container.State,
+			valueOrDash(container.Health),
+			valueOrDash(containerUptime(container)),
+		)
 	}
+}
 
-	// get mapstruct of response body - can't unmarshal directly due to https://github.com/compose-spec/compose-go/issues/353
-	var body map[string]interface{}
-	if err := json.Unmarshal(buf, &body); err != nil {
-		return err
+// containerUptime returns how long a running container has been up, from its status, e.g. `Up 2 hours (healthy)`.
+func containerUptime(container casaos.Container) string {
+	if !strings.HasPrefix(container.Status, "Up ") {
+		return ""
 	}
 
-	_, ok := body["data"]
-	if !ok {
-		return fmt.Errorf("body does not contain `data`")
+	uptime := strings.TrimPrefix(container.Status, "Up ")
+	if i := strings.Index(uptime, " ("); i >= 0 {
+		uptime = uptime[:i]
 	}
 
-	data, ok := body["data"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("data is not a map[string]interface")
-	}
+	return uptime
+}
 
-	storeInfo, err := composeAppStoreInfo(data)
-	if err != nil {
-		return err
-	}
+// imageTag returns the tag of an image reference, which is `latest` if not given.
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
 
-	mainApp := "unknown"
-	if storeInfo.Main != nil {
-		mainApp = *storeInfo.Main
+	// a colon before the last slash is the port of the registry, not the tag
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
 	}
 
-	for name, app := range *storeInfo.Apps {
-		var buf bytes.Buffer
+	return "latest"
+}
 
-		enc := json.NewEncoder(&buf)
-		enc.SetIndent("", "  ")
+// maskSecret masks the value of an environment variable if its name looks like a secret, e.g. `DB_PASSWORD`.
+func maskSecret(key, value string) string {
+	if value == "" {
+		return value
+	}
 
-		if err := enc.Encode(app); err != nil {
-			return err
-		}
+	words := strings.FieldsFunc(strings.ToUpper(camelCaseBoundary.ReplaceAllString(key, "${1}_${2}")), func(r rune) bool {
+		return (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	})
 
-		line := name
-		if name == mainApp {
-			line += " (main)"
+	for _, word := range words {
+		if lo.Contains(secretWords, word) {
+			return "********"
 		}
-		fmt.Fprintln(writer, line)
-		fmt.Fprintln(writer, strings.Repeat("-", len(line)))
+	}
 
-		if useColor {
-			if err := quick.Highlight(writer, buf.String(), "json", "terminal8", "native"); err != nil {
-				return err
-			}
-		} else {
-			if _, err := io.WriteString(writer, buf.String()); err != nil {
-				return err
-			}
-		}
+	return value
+}
 
-		fmt.Fprintln(writer)
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
 	}
+	return strings.Join(lines, "\n")
+}
 
-	return nil
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "testing"
+
+func TestAppManagementShowLocal(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "app-management", "show", "local", "jellyfin")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "app_management_show_local", stdout)
+}
+
+func TestMaskSecret(t *testing.T) {
+	for key, masked := range map[string]bool{
+		"DB_PASSWORD":                 true,
+		"API_KEY":                     true,
+		"apiToken":                    true,
+		"JELLYFIN_PublishedServerUrl": false,
+		"KEYBOARD_LAYOUT":             false,
+		"PUID":                        false,
+	} {
+		if actual := maskSecret(key, "value") != "value"; actual != masked {
+			t.Errorf("expected %s masked to be %t, got %t", key, masked, actual)
+		}
+	}
+}
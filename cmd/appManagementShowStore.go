@@ -0,0 +1,472 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/alecthomas/chroma/quick"
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/mitchellh/mapstructure"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// extension of compose files for CasaOS store info, at both app and service level
+	ComposeExtensionCasaOS = "x-casaos"
+
+	storeDownloadTimeout = 1 * time.Minute
+)
+
+// storeServiceInfo is store info of a service, from the `x-casaos` extension of the service.
+type storeServiceInfo struct {
+	Envs    []storeServiceItem `mapstructure:"envs"`
+	Ports   []storeServiceItem `mapstructure:"ports"`
+	Volumes []storeServiceItem `mapstructure:"volumes"`
+}
+
+type storeServiceItem struct {
+	Container   string            `mapstructure:"container"`
+	Description map[string]string `mapstructure:"description"`
+}
+
+// storeAppVersion is the version of an app provided by a registered app store.
+type storeAppVersion struct {
+	Store   casaos.AppStore
+	Version string
+	Err     error
+}
+
+// appManagementShowStoreCmd represents the appManagementShowStore command
+var appManagementShowStoreCmd = &cobra.Command{
+	Use:   "store <store-app-id>",
+	Short: "show information of an app in app store",
+	Long: `Show an app from registered app stores, whether it is installed or not: localized title, description and tips,
+icon and screenshots, architectures, ports, volumes and environment variables, and which registered app stores
+provide it at which version.
+
+Use --yaml to show the compose file exactly as it is submitted by install.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		useYAML, err := cmd.Flags().GetBool(FlagAppManagementYAML)
+		if err != nil {
+			return err
+		}
+
+		useColor, err := cmd.Flags().GetBool(FlagAppManagementUseColor)
+		if err != nil {
+			return err
+		}
+
+		language, err := cmd.Flags().GetString(FlagAppManagementLanguage)
+		if err != nil {
+			return err
+		}
+
+		storeAppID := args[0]
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		composeYAML, err := client.Store().Compose(ctx, storeAppID)
+		if err != nil {
+			return err
+		}
+
+		if useYAML {
+			if useColor {
+				return quick.Highlight(cmd.OutOrStdout(), string(composeYAML), "yaml", "terminal8", "native")
+			}
+
+			_, err := cmd.OutOrStdout().Write(composeYAML)
+			return err
+		}
+
+		project, err := parseComposeProject(storeAppID+".yaml", composeYAML, os.TempDir(), func(o *loader.Options) {
+			o.SkipInterpolation = true
+		})
+		if err != nil {
+			return err
+		}
+
+		stores, err := client.Store().Stores(ctx)
+		if err != nil {
+			return err
+		}
+
+		downloadCtx, downloadCancel := context.WithTimeout(context.Background(), storeDownloadTimeout)
+		defer downloadCancel()
+
+		versions := storeAppVersions(downloadCtx, stores, storeAppID)
+
+		return showStoreApp(cmd.OutOrStdout(), storeAppID, project, versions, language)
+	},
+}
+
+func init() {
+	appManagementShowCmd.AddCommand(appManagementShowStoreCmd)
+
+	appManagementShowStoreCmd.Flags().Bool(FlagAppManagementYAML, false, "output the compose file in raw YAML format")
+	appManagementShowStoreCmd.Flags().BoolP(FlagAppManagementUseColor, "c", false, "colorize output")
+	appManagementShowStoreCmd.Flags().String(FlagAppManagementLanguage, DefaultLanguage, "language of title, description and tips, e.g. zh_cn")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// appManagementShowStoreCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// appManagementShowStoreCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+func showStoreApp(writer io.Writer, storeAppID string, project *types.Project, versions []storeAppVersion, language string) error {
+	var info casaos.StoreInfo
+	if err := mapstructure.WeakDecode(project.Extensions[ComposeExtensionCasaOS], &info); err != nil {
+		return fmt.Errorf("invalid %s in compose file - %w", ComposeExtensionCasaOS, err)
+	}
+
+	title := casaos.Localized(info.Title, language)
+	if title == "" {
+		title = storeAppID
+	}
+
+	heading := fmt.Sprintf("%s (%s)", title, storeAppID)
+	fmt.Fprintln(writer, heading)
+	fmt.Fprintln(writer, strings.Repeat("=", utf8.RuneCountInString(heading)))
+
+	if tagline := casaos.Localized(info.Tagline, language); tagline != "" {
+		fmt.Fprintln(writer, tagline)
+		fmt.Fprintln(writer)
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+
+	for _, service := range project.Services {
+		if service.Name == info.Main || len(project.Services) == 1 {
+			fmt.Fprintf(w, "Version:\t%s (%s)\n", imageTag(service.Image), service.Image)
+		}
+	}
+
+	fmt.Fprintf(w, "Author:\t%s\n", valueOrDash(info.Author))
+	fmt.Fprintf(w, "Developer:\t%s\n", valueOrDash(info.Developer))
+	fmt.Fprintf(w, "Category:\t%s\n", valueOrDash(info.Category))
+	fmt.Fprintf(w, "Architectures:\t%s\n", valueOrDash(strings.Join(info.Architectures, ", ")))
+	fmt.Fprintf(w, "Icon:\t%s\n", valueOrDash(info.Icon))
+	fmt.Fprintf(w, "Thumbnail:\t%s\n", valueOrDash(info.Thumbnail))
+
+	w.Flush()
+
+	if len(info.ScreenshotLink) > 0 {
+		fmt.Fprintln(writer, "Screenshots:")
+		for _, link := range info.ScreenshotLink {
+			fmt.Fprintln(writer, "  "+link)
+		}
+	}
+
+	if description := casaos.Localized(info.Description, language); description != "" {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Description:")
+		fmt.Fprintln(writer, indent(description, "  "))
+	}
+
+	if tips := casaos.Localized(info.Tips.BeforeInstall, language); tips != "" {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Tips:")
+		fmt.Fprintln(writer, indent(tips, "  "))
+	}
+
+	services := map[string]storeServiceInfo{}
+	for _, service := range project.Services {
+		var serviceInfo storeServiceInfo
+		if err := mapstructure.WeakDecode(service.Extensions[ComposeExtensionCasaOS], &serviceInfo); err != nil {
+			return fmt.Errorf("invalid %s in service %s - %w", ComposeExtensionCasaOS, service.Name, err)
+		}
+		services[service.Name] = serviceInfo
+	}
+
+	describe := func(items []storeServiceItem, container string) string {
+		for _, item := range items {
+			if item.Container == container {
+				return casaos.Localized(item.Description, language)
+			}
+		}
+		return ""
+	}
+
+	fmt.Fprintln(writer)
+
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tPUBLISHED\tTARGET\tPROTOCOL\tDESCRIPTION")
+	fmt.Fprintln(w, "-------\t---------\t------\t--------\t-----------")
+	for _, service := range project.Services {
+		for _, port := range service.Ports {
+			target := fmt.Sprint(port.Target)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", service.Name, valueOrDash(port.Published), target, lo.If(port.Protocol != "", port.Protocol).Else("tcp"), describe(services[service.Name].Ports, target))
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintln(writer)
+
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tTYPE\tSOURCE\tTARGET\tDESCRIPTION")
+	fmt.Fprintln(w, "-------\t----\t------\t------\t-----------")
+	for _, service := range project.Services {
+		for _, volume := range service.Volumes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", service.Name, valueOrDash(volume.Type), valueOrDash(volume.Source), volume.Target, describe(services[service.Name].Volumes, volume.Target))
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintln(writer)
+
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tENVIRONMENT VARIABLE\tVALUE\tDESCRIPTION")
+	fmt.Fprintln(w, "-------\t--------------------\t-----\t-----------")
+	for _, service := range project.Services {
+		keys := lo.Keys(service.Environment)
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service.Name, key, maskSecret(key, lo.FromPtr(service.Environment[key])), describe(services[service.Name].Envs, key))
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintln(writer)
+
+	w = tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintln(w, "APP STORE ID\tURL\tVERSION")
+	fmt.Fprintln(w, "------------\t---\t-------")
+	for _, version := range versions {
+		v := version.Version
+		if version.Err != nil {
+			v = fmt.Sprintf("unknown - %s", version.Err.Error())
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", version.Store.ID, version.Store.URL, v)
+	}
+
+	return w.Flush()
+}
+
+// storeAppVersions downloads each registered app store, or reuses its cached copy if unchanged, and returns the version of the app in the stores providing it.
+func storeAppVersions(ctx context.Context, stores []casaos.AppStore, storeAppID string) []storeAppVersion {
+	versions := []storeAppVersion{}
+
+	for _, store := range stores {
+		version, found, err := storeAppVersionIn(ctx, store.URL, storeAppID)
+		if err != nil {
+			versions = append(versions, storeAppVersion{Store: store, Err: err})
+			continue
+		}
+
+		if found {
+			versions = append(versions, storeAppVersion{Store: store, Version: version})
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Store.ID < versions[j].Store.ID })
+
+	return versions
+}
+
+// storeAppVersionIn looks for `Apps/<store-app-id>/docker-compose.yml` in the ZIP file of an app store, and returns
+// the image tag of the main service as version.
+func storeAppVersionIn(ctx context.Context, storeURL, storeAppID string) (string, bool, error) {
+	zipPath, err := cachedStoreZip(ctx, storeURL)
+	if err != nil {
+		return "", false, err
+	}
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", false, err
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		dir, name := path.Split(file.Name)
+		if name != "docker-compose.yml" && name != "docker-compose.yaml" {
+			continue
+		}
+
+		appDir, appsDir := path.Base(dir), path.Base(path.Dir(strings.TrimSuffix(dir, "/")))
+		if appsDir != "Apps" || !strings.EqualFold(appDir, storeAppID) {
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return "", true, err
+		}
+
+		var compose struct {
+			Services map[string]struct {
+				Image string `yaml:"image"`
+			} `yaml:"services"`
+			CasaOS struct {
+				Main string `yaml:"main"`
+			} `yaml:"x-casaos"`
+		}
+
+		if err := yaml.Unmarshal(content, &compose); err != nil {
+			return "", true, err
+		}
+
+		main := compose.CasaOS.Main
+		if main == "" && len(compose.Services) == 1 {
+			main = lo.Keys(compose.Services)[0]
+		}
+
+		image := compose.Services[main].Image
+		if image == "" {
+			return "", true, fmt.Errorf("image of main service is not found")
+		}
+
+		return imageTag(image), true, nil
+	}
+
+	return "", false, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// storeZipValidator is saved next to a cached ZIP file of an app store, to ask the server if it has changed since.
+type storeZipValidator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// cachedStoreZip returns the path of the ZIP file of an app store in the user cache directory, e.g.
+// ~/.cache/casaos-cli/app-stores, which is only downloaded again when the server tells it has changed.
+func cachedStoreZip(ctx context.Context, storeURL string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(cacheDir, "casaos-cli", "app-stores")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(storeURL))
+	zipPath := filepath.Join(dir, hex.EncodeToString(sum[:])+".zip")
+	validatorPath := zipPath + ".json"
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, storeURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var validator storeZipValidator
+	if _, err := os.Stat(zipPath); err == nil {
+		if buf, err := os.ReadFile(validatorPath); err == nil && json.Unmarshal(buf, &validator) == nil {
+			if validator.ETag != "" {
+				request.Header.Set("If-None-Match", validator.ETag)
+			}
+
+			if validator.LastModified != "" {
+				request.Header.Set("If-Modified-Since", validator.LastModified)
+			}
+		}
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		return zipPath, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", response.Status)
+	}
+
+	// download to a temporary file first, so that an interrupted download does not replace the cached one
+	f, err := os.CreateTemp(dir, "download-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, response.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(f.Name(), zipPath); err != nil {
+		return "", err
+	}
+
+	validator = storeZipValidator{
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+	}
+
+	buf, err := json.Marshal(validator)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(validatorPath, buf, 0o600); err != nil {
+		return "", err
+	}
+
+	return zipPath, nil
+}
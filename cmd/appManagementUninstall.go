@@ -17,12 +17,9 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"net/http"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
-	"github.com/IceWhaleTech/CasaOS-Common/utils"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/spf13/cobra"
 )
 
@@ -35,19 +32,12 @@ var appManagementUninstallCmd = &cobra.Command{
 	Short:   "uninstall a compose app",
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
 		noRemoveConfigFolder, err := cmd.Flags().GetBool(FlagAppManagementUninstallNoRemoveConfig)
 		if err != nil {
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -56,23 +46,12 @@ var appManagementUninstallCmd = &cobra.Command{
 		defer cancel()
 
 		appID := cmd.Flags().Arg(0)
-		response, err := client.UninstallComposeAppWithResponse(ctx, appID, &app_management.UninstallComposeAppParams{
-			DeleteConfigFolder: utils.Ptr(!noRemoveConfigFolder),
-		})
+		message, err := client.Apps().Uninstall(ctx, appID, casaos.UninstallOptions{KeepConfig: noRemoveConfigFolder})
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
-		log.Println(*response.JSON200.Message)
+		log.Println(message)
 
 		return nil
 	},
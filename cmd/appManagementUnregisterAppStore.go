@@ -18,10 +18,8 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"strconv"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
 	"github.com/spf13/cobra"
 )
 
@@ -39,19 +37,12 @@ var appManagementUnregisterAppStoreCmd = &cobra.Command{
 		return nil
 	}),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
 		appStoreID, err := strconv.Atoi(cmd.Flags().Arg(0))
 		if err != nil || appStoreID < 0 {
 			return fmt.Errorf("how can it get here?? should have been validated in cobra.MatchAll(...)")
 		}
 
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -59,26 +50,12 @@ var appManagementUnregisterAppStoreCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.UnregisterAppStoreWithResponse(ctx, appStoreID)
+		message, err := client.Store().Unregister(ctx, appStoreID)
 		if err != nil {
 			return err
 		}
 
-		var baseResponse app_management.BaseResponse
-
-		if response.StatusCode() != http.StatusOK {
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
-		if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-			return fmt.Errorf("%s - %s", response.Status(), response.Body)
-		}
-
-		fmt.Println(*baseResponse.Message)
+		fmt.Fprintln(cmd.OutOrStdout(), message)
 
 		return nil
 	},
@@ -17,11 +17,9 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"net/http"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/app_management"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/spf13/cobra"
 )
 
@@ -31,18 +29,11 @@ var appManagementUpdateAppCmd = &cobra.Command{
 	Short: "update a compose app",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathAppManagement)
-
 		appID := cmd.Flags().Arg(0)
 
 		force := cmd.Flag(FlagForce).Value.String() == "true"
 
-		client, err := app_management.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
@@ -50,21 +41,12 @@ var appManagementUpdateAppCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		response, err := client.UpdateComposeAppWithResponse(ctx, appID, &app_management.UpdateComposeAppParams{Force: &force})
+		message, err := client.Apps().Update(ctx, appID, casaos.UpdateOptions{Force: force})
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse app_management.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
-
-		log.Println(*response.JSON200.Message)
+		log.Println(message)
 
 		return nil
 	},
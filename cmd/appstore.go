@@ -0,0 +1,495 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/mitchellh/mapstructure"
+	"github.com/samber/lo"
+)
+
+// layout of an app store directory, as in https://github.com/IceWhaleTech/CasaOS-AppStore
+const (
+	StoreAppsDir           = "Apps"
+	StoreComposeFile       = "docker-compose.yml"
+	StoreCategoryListFile  = "category-list.json"
+	StoreRecommendListFile = "recommend-list.json"
+	StoreManifestFile      = "SHA256SUMS"
+)
+
+var (
+	// architectures of Docker images that CasaOS runs on
+	storeArchitectures = []string{"amd64", "arm64", "arm"}
+
+	storeLanguagePattern = regexp.MustCompile(`^[a-z]{2}_[a-z]{2}$`)
+)
+
+// storeCategory is an entry of `category-list.json`.
+type storeCategory struct {
+	Name        string `json:"name"`
+	Font        string `json:"font"`
+	Description string `json:"description"`
+}
+
+// storeRecommend is an entry of `recommend-list.json`.
+type storeRecommend struct {
+	AppID string `json:"appid"`
+}
+
+// storeDirApp is an app in `Apps/<dir>` of an app store directory.
+type storeDirApp struct {
+	// ID is the store app ID, i.e. the project name of the compose file, or the directory name if not set.
+	ID      string
+	Dir     string
+	Project *types.Project
+	Info    casaos.StoreInfo
+}
+
+// storeIssue is a problem found in an app store directory. Warnings do not stop the store from being served.
+type storeIssue struct {
+	Path    string
+	Message string
+	Warning bool
+}
+
+func (i storeIssue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+
+	return fmt.Sprintf("%s: %s - %s", level, i.Path, i.Message)
+}
+
+// storeDir is an app store directory, loaded from disk along with any issues found.
+type storeDir struct {
+	Root       string
+	Apps       []storeDirApp
+	Categories []storeCategory
+	Recommends []storeRecommend
+	Issues     []storeIssue
+}
+
+func (s *storeDir) errorf(path, format string, a ...interface{}) {
+	s.Issues = append(s.Issues, storeIssue{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (s *storeDir) warnf(path, format string, a ...interface{}) {
+	s.Issues = append(s.Issues, storeIssue{Path: path, Message: fmt.Sprintf(format, a...), Warning: true})
+}
+
+// Errors returns issues that are not warnings.
+func (s *storeDir) Errors() []storeIssue {
+	errors := []storeIssue{}
+
+	for _, issue := range s.Issues {
+		if !issue.Warning {
+			errors = append(errors, issue)
+		}
+	}
+
+	return errors
+}
+
+// loadStoreDir loads and validates an app store directory. Problems with the content are returned as issues of the
+// store, while an error is only returned if root is not a directory at all.
+func loadStoreDir(root string) (*storeDir, error) {
+	stat, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	store := &storeDir{Root: root}
+
+	store.loadApps()
+	store.loadCategories()
+	store.loadRecommends()
+
+	return store, nil
+}
+
+func (s *storeDir) loadApps() {
+	entries, err := os.ReadDir(filepath.Join(s.Root, StoreAppsDir))
+	if err != nil {
+		s.errorf(StoreAppsDir, "%s", err.Error())
+		return
+	}
+
+	ids := map[string]string{}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		dir := filepath.Join(StoreAppsDir, entry.Name())
+		composePath := filepath.Join(dir, StoreComposeFile)
+
+		buf, err := os.ReadFile(filepath.Join(s.Root, composePath))
+		if err != nil {
+			if os.IsNotExist(err) {
+				s.errorf(dir, "%s is not found", StoreComposeFile)
+			} else {
+				s.errorf(composePath, "%s", err.Error())
+			}
+			continue
+		}
+
+		project, err := parseComposeProject(StoreComposeFile, buf, filepath.Join(s.Root, dir), func(o *loader.Options) {
+			o.SkipInterpolation = true
+		})
+		if err != nil {
+			s.errorf(composePath, "invalid compose file - %s", err.Error())
+			continue
+		}
+
+		app := storeDirApp{ID: project.Name, Dir: entry.Name(), Project: project}
+		if app.ID == "" {
+			app.ID = strings.ToLower(entry.Name())
+		}
+
+		if err := mapstructure.WeakDecode(project.Extensions[ComposeExtensionCasaOS], &app.Info); err != nil {
+			s.errorf(composePath, "invalid %s - %s", ComposeExtensionCasaOS, err.Error())
+			continue
+		}
+
+		if _, ok := project.Extensions[ComposeExtensionCasaOS]; !ok {
+			s.errorf(composePath, "%s is not found", ComposeExtensionCasaOS)
+		} else if app.Info.Main == "" {
+			if len(project.Services) != 1 {
+				s.errorf(composePath, "main service is not set in %s", ComposeExtensionCasaOS)
+			}
+		} else if _, err := project.GetService(app.Info.Main); err != nil {
+			s.errorf(composePath, "main service %s is not found", app.Info.Main)
+		}
+
+		if other, ok := ids[strings.ToLower(app.ID)]; ok {
+			s.errorf(composePath, "store app id %s is already used by %s", app.ID, other)
+			continue
+		}
+
+		ids[strings.ToLower(app.ID)] = dir
+
+		s.Apps = append(s.Apps, app)
+	}
+
+	if len(s.Apps) == 0 && len(s.Errors()) == 0 {
+		s.warnf(StoreAppsDir, "no app is found")
+	}
+}
+
+func (s *storeDir) loadCategories() {
+	buf, err := os.ReadFile(filepath.Join(s.Root, StoreCategoryListFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.warnf(StoreCategoryListFile, "not found - apps will not be listed by category")
+		} else {
+			s.errorf(StoreCategoryListFile, "%s", err.Error())
+		}
+		return
+	}
+
+	if err := json.Unmarshal(buf, &s.Categories); err != nil {
+		s.errorf(StoreCategoryListFile, "invalid JSON - %s", err.Error())
+		return
+	}
+
+	names := map[string]bool{}
+
+	for i, category := range s.Categories {
+		if category.Name == "" {
+			s.errorf(StoreCategoryListFile, "name of category #%d is empty", i+1)
+			continue
+		}
+
+		names[strings.ToLower(category.Name)] = true
+	}
+
+	for _, app := range s.Apps {
+		if app.Info.Category != "" && !names[strings.ToLower(app.Info.Category)] {
+			s.warnf(filepath.Join(StoreAppsDir, app.Dir, StoreComposeFile), "category %s is not in %s", app.Info.Category, StoreCategoryListFile)
+		}
+	}
+}
+
+func (s *storeDir) loadRecommends() {
+	buf, err := os.ReadFile(filepath.Join(s.Root, StoreRecommendListFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.errorf(StoreRecommendListFile, "%s", err.Error())
+		}
+		return
+	}
+
+	if err := json.Unmarshal(buf, &s.Recommends); err != nil {
+		s.errorf(StoreRecommendListFile, "invalid JSON - %s", err.Error())
+		return
+	}
+
+	ids := map[string]bool{}
+	for _, app := range s.Apps {
+		ids[strings.ToLower(app.ID)] = true
+	}
+
+	for _, recommend := range s.Recommends {
+		if !ids[strings.ToLower(recommend.AppID)] {
+			s.errorf(StoreRecommendListFile, "recommended app %s is not found in %s", recommend.AppID, StoreAppsDir)
+		}
+	}
+}
+
+// lint checks store info of each app beyond what is needed to serve the app store: required and localized fields,
+// architectures, images, and icon and screenshots present in the app directory.
+func (s *storeDir) lint() {
+	for _, app := range s.Apps {
+		composePath := filepath.Join(StoreAppsDir, app.Dir, StoreComposeFile)
+
+		if app.Project.Name == "" {
+			s.errorf(composePath, "name is not set - it is the store app id, e.g. name: %s", strings.ToLower(app.Dir))
+		}
+
+		for _, service := range app.Project.Services {
+			if service.Image == "" {
+				s.errorf(composePath, "image of service %s is not set", service.Name)
+			}
+
+			var serviceInfo storeServiceInfo
+			if err := mapstructure.WeakDecode(service.Extensions[ComposeExtensionCasaOS], &serviceInfo); err != nil {
+				s.errorf(composePath, "invalid %s of service %s - %s", ComposeExtensionCasaOS, service.Name, err.Error())
+				continue
+			}
+
+			for _, items := range [][]storeServiceItem{serviceInfo.Envs, serviceInfo.Ports, serviceInfo.Volumes} {
+				for _, item := range items {
+					s.lintLocalized(composePath, fmt.Sprintf("description of %s in service %s", item.Container, service.Name), item.Description, true)
+				}
+			}
+		}
+
+		for _, required := range []struct{ field, value string }{
+			{"author", app.Info.Author},
+			{"developer", app.Info.Developer},
+			{"category", app.Info.Category},
+			{"icon", app.Info.Icon},
+		} {
+			if required.value == "" {
+				s.errorf(composePath, "%s is not set in %s", required.field, ComposeExtensionCasaOS)
+			}
+		}
+
+		if len(app.Info.Architectures) == 0 {
+			s.errorf(composePath, "architectures is not set in %s", ComposeExtensionCasaOS)
+		}
+
+		for _, architecture := range app.Info.Architectures {
+			if !lo.Contains(storeArchitectures, architecture) {
+				s.errorf(composePath, "unknown architecture %s, should be one of %s", architecture, strings.Join(storeArchitectures, ", "))
+			}
+		}
+
+		s.lintLocalized(composePath, "title", app.Info.Title, false)
+		s.lintLocalized(composePath, "description", app.Info.Description, false)
+		s.lintLocalized(composePath, "tagline", app.Info.Tagline, true)
+		s.lintLocalized(composePath, "tips.before_install", app.Info.Tips.BeforeInstall, true)
+
+		if len(app.Info.ScreenshotLink) == 0 {
+			s.warnf(composePath, "screenshot_link is not set in %s", ComposeExtensionCasaOS)
+		}
+
+		for _, link := range append([]string{app.Info.Icon, app.Info.Thumbnail}, app.Info.ScreenshotLink...) {
+			if link == "" {
+				continue
+			}
+
+			if asset, ok := storeAssetPath(app.Dir, link); ok {
+				if _, err := os.Stat(filepath.Join(s.Root, asset)); err != nil {
+					s.errorf(composePath, "%s is not found for %s", asset, link)
+				}
+			}
+		}
+	}
+}
+
+// lintLocalized checks that a localized field has the default language, and that its languages look like `zh_cn`.
+func (s *storeDir) lintLocalized(path, field string, values map[string]string, optional bool) {
+	if len(values) == 0 {
+		if optional {
+			s.warnf(path, "%s is not set", field)
+		} else {
+			s.errorf(path, "%s is not set", field)
+		}
+		return
+	}
+
+	if values[DefaultLanguage] == "" {
+		s.errorf(path, "%s has no %s text, which is shown when a language is not translated", field, DefaultLanguage)
+	}
+
+	languages := lo.Keys(values)
+	sort.Strings(languages)
+
+	for _, language := range languages {
+		if !storeLanguagePattern.MatchString(language) {
+			s.warnf(path, "language %s of %s should look like %s", language, field, DefaultLanguage)
+		}
+	}
+}
+
+// storeAssetPath returns the path of an icon or screenshot in the app store directory, if it is relative to the app
+// directory, or a URL to `Apps/<dir>/...` of the app store repository, e.g. via a CDN like jsDelivr. Other URLs are
+// not checked.
+func storeAssetPath(appDir, link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", false
+	}
+
+	if u.Scheme == "" && u.Host == "" {
+		return filepath.Join(StoreAppsDir, appDir, filepath.FromSlash(u.Path)), true
+	}
+
+	marker := "/" + StoreAppsDir + "/" + appDir + "/"
+
+	i := strings.Index(strings.ToLower(u.Path), strings.ToLower(marker))
+	if i < 0 {
+		return "", false
+	}
+
+	return filepath.Join(StoreAppsDir, appDir, filepath.FromSlash(u.Path[i+len(marker):])), true
+}
+
+// writeStoreArchive writes every file of the app store directory to w as a ZIP file, under a top directory named
+// prefix, the same way as a GitHub archive of an app store repository. Hidden files like `.git` are skipped. With
+// manifest, a `SHA256SUMS` file with the checksum of every other file is added, in the format of `sha256sum`.
+func writeStoreArchive(w io.Writer, root, prefix string, manifest bool) error {
+	paths := []string{}
+
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if manifest && path == filepath.Join(root, StoreManifestFile) {
+			return nil
+		}
+
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	sort.Strings(paths)
+
+	archive := zip.NewWriter(w)
+
+	var sums strings.Builder
+
+	for _, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		checksum, err := addStoreArchiveFile(archive, path, prefix+"/"+filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&sums, "%s  %s\n", checksum, filepath.ToSlash(rel))
+	}
+
+	if manifest {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     prefix + "/" + StoreManifestFile,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(writer, sums.String()); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// addStoreArchiveFile adds the file at path to the archive as name, and returns its SHA-256 checksum.
+func addStoreArchiveFile(archive *zip.Writer, path, name string) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := zip.FileInfoHeader(stat)
+	if err != nil {
+		return "", err
+	}
+
+	header.Name = name
+	header.Method = zip.Deflate
+
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(writer, hash), f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
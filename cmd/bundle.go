@@ -0,0 +1,175 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/samber/lo"
+)
+
+// layout of an app bundle, which is a tar archive with the manifest first
+const (
+	BundleManifestFile = "manifest.json"
+	BundleComposeFile  = "docker-compose.yml"
+	BundleImagesFile   = "images.tar"
+
+	bundleVersion = 1
+)
+
+// where the compose file of a bundle comes from
+const (
+	BundleSourceLocal = "local"
+	BundleSourceStore = "store"
+)
+
+// bundleManifest describes an app bundle, with digests to verify its content on import.
+type bundleManifest struct {
+	Version int           `json:"version"`
+	AppID   string        `json:"app_id"`
+	Source  string        `json:"source"`
+	Created time.Time     `json:"created"`
+	Images  []bundleImage `json:"images"`
+
+	// Files maps other files in the bundle to their digests, e.g. `sha256:...`.
+	Files map[string]string `json:"files"`
+}
+
+// bundleImage is an image saved in `images.tar` of a bundle. ID is the image ID in Docker, i.e. the digest of its
+// config, which stays the same when the image is saved and loaded again.
+type bundleImage struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// digestWriter computes the digest of everything written to it.
+type digestWriter struct {
+	hash hash.Hash
+}
+
+func newDigestWriter() *digestWriter {
+	return &digestWriter{hash: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	return d.hash.Write(p)
+}
+
+// Digest returns the digest in the form of `sha256:<hex>`.
+func (d *digestWriter) Digest() string {
+	return "sha256:" + hex.EncodeToString(d.hash.Sum(nil))
+}
+
+// projectImages returns the images of every service in the project, with `:latest` added if there is no tag, so the
+// exact image is saved instead of every tag of the repository.
+func projectImages(project *types.Project) []string {
+	images := []string{}
+
+	for _, service := range project.Services {
+		if service.Image == "" {
+			continue
+		}
+
+		image := service.Image
+		if !strings.Contains(image, "@") && imageTag(image) == "latest" && !strings.HasSuffix(image, ":latest") {
+			image += ":latest"
+		}
+
+		images = append(images, image)
+	}
+
+	images = lo.Uniq(images)
+	sort.Strings(images)
+
+	return images
+}
+
+// writeBundleEntry adds the content of r with the given size to the bundle as name.
+func writeBundleEntry(w *tar.Writer, name string, size int64, r io.Reader) error {
+	if err := w.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: time.Now(),
+		Format:  tar.FormatPAX,
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// readBundleManifest reads the manifest, which must be the first entry of the bundle.
+func readBundleManifest(r *tar.Reader) (*bundleManifest, error) {
+	header, err := r.Next()
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle - %w", err)
+	}
+
+	if header.Name != BundleManifestFile {
+		return nil, fmt.Errorf("invalid bundle - %s is not the first file", BundleManifestFile)
+	}
+
+	var manifest bundleManifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s - %w", BundleManifestFile, err)
+	}
+
+	if manifest.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d, only %d is supported", manifest.Version, bundleVersion)
+	}
+
+	return &manifest, nil
+}
+
+// spoolBundleEntry copies an entry of the bundle to a temporary file, and returns the file with its digest. The file
+// is removed when closed with removeTemp.
+func spoolBundleEntry(r io.Reader) (*os.File, string, error) {
+	f, err := os.CreateTemp("", "casaos-bundle-*")
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := newDigestWriter()
+
+	if _, err := io.Copy(io.MultiWriter(f, digest), r); err != nil {
+		removeTemp(f)
+		return nil, "", err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		removeTemp(f)
+		return nil, "", err
+	}
+
+	return f, digest.Digest(), nil
+}
+
+func removeTemp(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
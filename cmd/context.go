@@ -0,0 +1,202 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FlagContext = "context"
+
+	EnvContext = "CASAOS_CONTEXT"
+
+	contextsFilename = "contexts.yaml"
+)
+
+// contextCmd represents the context command
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "manage contexts, i.e. named CasaOS hosts and how to reach them",
+	Long: `Manage contexts, i.e. named CasaOS hosts and how to reach them.
+
+A context stores the root url and the SSH target of a CasaOS host, so that they do not have to be given with
+--root-url and --ssh every time. The context is selected with --context, $` + EnvContext + `, or 'context use'.
+Flags and environment variables given explicitly still take precedence over the context.
+
+Access tokens are not stored in contexts.`,
+	Aliases: []string{"ctx"},
+
+	// contexts are managed without connecting to any of them
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutput(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// contextCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// contextCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// cliContexts is the content of the contexts file.
+type cliContexts struct {
+	Current  string                `yaml:"current,omitempty"`
+	Contexts map[string]cliContext `yaml:"contexts"`
+}
+
+// cliContext is how to reach a CasaOS host.
+type cliContext struct {
+	RootURL string `yaml:"root_url,omitempty"`
+	SSH     string `yaml:"ssh,omitempty"`
+}
+
+// contextsPath returns where contexts are stored, e.g. ~/.config/casaos-cli/contexts.yaml
+func contextsPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "casaos-cli", contextsFilename), nil
+}
+
+// loadContexts loads contexts from the contexts file, or returns no contexts if the file does not exist yet.
+func loadContexts() (*cliContexts, error) {
+	contexts := &cliContexts{Contexts: map[string]cliContext{}}
+
+	path, err := contextsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return contexts, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(buf, contexts); err != nil {
+		return nil, fmt.Errorf("contexts file %s is invalid: %w", path, err)
+	}
+
+	if contexts.Contexts == nil {
+		contexts.Contexts = map[string]cliContext{}
+	}
+
+	return contexts, nil
+}
+
+func saveContexts(contexts *cliContexts) error {
+	path, err := contextsPath()
+	if err != nil {
+		return err
+	}
+
+	buf, err := yaml.Marshal(contexts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf, 0o600)
+}
+
+func (c *cliContexts) names() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// get returns the context of the name, or a not found error listing contexts that exist.
+func (c *cliContexts) get(name string) (cliContext, error) {
+	context, ok := c.Contexts[name]
+	if !ok {
+		return cliContext{}, notFoundError{err: fmt.Errorf("context %s not found - use `context list` to see all contexts", name)}
+	}
+
+	return context, nil
+}
+
+// setupContext applies the selected context to --root-url and --ssh, unless they are given by flags or environment
+// variables.
+func setupContext(cmd *cobra.Command) error {
+	name, err := cmd.Flags().GetString(FlagContext)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = os.Getenv(EnvContext)
+	}
+
+	contexts, err := loadContexts()
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = contexts.Current
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	context, err := contexts.get(name)
+	if err != nil {
+		return err
+	}
+
+	if context.RootURL != "" && !cmd.Flags().Changed(FlagRootURL) {
+		if err := cmd.Flags().Set(FlagRootURL, context.RootURL); err != nil {
+			return err
+		}
+	}
+
+	if context.SSH != "" && !cmd.Flags().Changed(FlagSSH) && os.Getenv(EnvSSH) == "" {
+		if err := cmd.Flags().Set(FlagSSH, context.SSH); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
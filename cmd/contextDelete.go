@@ -0,0 +1,70 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// contextDeleteCmd represents the contextDelete command
+var contextDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Short:   "delete a context",
+	Aliases: []string{"remove", "rm"},
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		contexts, err := loadContexts()
+		if err != nil {
+			return err
+		}
+
+		if _, err := contexts.get(name); err != nil {
+			return err
+		}
+
+		delete(contexts.Contexts, name)
+
+		if contexts.Current == name {
+			contexts.Current = ""
+		}
+
+		if err := saveContexts(contexts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "context %s deleted\n", name)
+
+		return nil
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextDeleteCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// contextDeleteCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// contextDeleteCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
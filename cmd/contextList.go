@@ -0,0 +1,74 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// contextListCmd represents the contextList command
+var contextListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list all contexts, with the current one marked by *",
+	Aliases: []string{"ls"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contexts, err := loadContexts()
+		if err != nil {
+			return err
+		}
+
+		if len(contexts.Contexts) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No contexts - use `context set` to create one")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "CURRENT\tNAME\tROOT_URL\tSSH")
+		fmt.Fprintln(w, "-------\t----\t--------\t---")
+
+		for _, name := range contexts.names() {
+			context := contexts.Contexts[name]
+
+			current := ""
+			if name == contexts.Current {
+				current = "*"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, valueOrDash(context.RootURL), valueOrDash(context.SSH))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// contextListCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// contextListCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
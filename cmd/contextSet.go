@@ -0,0 +1,95 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// contextSetCmd represents the contextSet command
+var contextSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "create or update a context with the given --root-url and --ssh",
+	Example: `
+# a remote CasaOS reached through SSH, with root url resolved on the remote host
+$ casaos-cli context set home --ssh pi@casaos.local
+
+# a CasaOS reached directly
+$ casaos-cli context set office --root-url 192.168.1.10:8080`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		contexts, err := loadContexts()
+		if err != nil {
+			return err
+		}
+
+		context := contexts.Contexts[name]
+
+		if cmd.Flags().Changed(FlagRootURL) {
+			if context.RootURL, err = cmd.Flags().GetString(FlagRootURL); err != nil {
+				return err
+			}
+		}
+
+		if cmd.Flags().Changed(FlagSSH) {
+			if context.SSH, err = cmd.Flags().GetString(FlagSSH); err != nil {
+				return err
+			}
+
+			if context.SSH != "" {
+				if _, _, err := parseSSHTarget(context.SSH); err != nil {
+					return usageError{err: err}
+				}
+			}
+		}
+
+		if context.RootURL == "" && context.SSH == "" {
+			return usageError{err: fmt.Errorf("either --%s or --%s should be given", FlagRootURL, FlagSSH)}
+		}
+
+		contexts.Contexts[name] = context
+
+		if contexts.Current == "" {
+			contexts.Current = name
+		}
+
+		if err := saveContexts(contexts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "context %s saved\n", name)
+
+		return nil
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextSetCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// contextSetCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// contextSetCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
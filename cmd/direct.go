@@ -0,0 +1,254 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/IceWhaleTech/CasaOS-Common/external"
+	"github.com/IceWhaleTech/CasaOS-Common/utils/constants"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagDirect = "direct"
+)
+
+// serviceEndpoint is a CasaOS service that writes its listen address to a runtime url file, and the base paths
+// it serves behind the gateway.
+type serviceEndpoint struct {
+	Service   string
+	URLFile   string
+	BasePaths []string
+}
+
+// serviceEndpoints is ordered so that the first matching base path wins, i.e. more specific ones go first.
+var serviceEndpoints = []serviceEndpoint{
+	{Service: "casaos-app-management", URLFile: "app-management.url", BasePaths: []string{BasePathAppManagement}},
+	{Service: "casaos-message-bus", URLFile: "message-bus.url", BasePaths: []string{BasePathMessageBus}},
+	{Service: "casaos-local-storage", URLFile: "local-storage.url", BasePaths: []string{BasePathLocalStorage, PathLocalStorageDisksV1, PathLocalStorageStorageV1, "v1/usb"}},
+	{Service: "casaos-user-service", URLFile: "user-service.url", BasePaths: []string{BasePathUsers, BasePathUsersV1}},
+	{Service: "casaos", URLFile: "casaos.url", BasePaths: []string{BasePathCasaOS, "v1"}},
+	{Service: "casaos-gateway", URLFile: external.ManagementURLFilename},
+}
+
+// directTransport sends requests for the gateway to the owning service directly, either always with `--direct`,
+// or as a fallback when the gateway refuses connection.
+type directTransport struct {
+	next        http.RoundTripper
+	gatewayHost string
+	always      bool
+
+	fallbackOnce sync.Once
+}
+
+// setupDirect wraps the default HTTP transport, so that requests to the gateway can be redirected to services.
+func setupDirect(cmd *cobra.Command) error {
+	direct, err := cmd.Flags().GetBool(FlagDirect)
+	if err != nil {
+		return err
+	}
+
+	// services on the remote host cannot be discovered from runtime url files on this host
+	if sshTunnel != nil {
+		if direct {
+			return usageError{err: fmt.Errorf("--%s cannot be used with --%s", FlagDirect, FlagSSH)}
+		}
+		return nil
+	}
+
+	rootURL, err := cmd.Flags().GetString(FlagRootURL)
+	if err != nil {
+		return err
+	}
+
+	http.DefaultTransport = &directTransport{
+		next:        http.DefaultTransport,
+		gatewayHost: strings.TrimRight(strings.TrimPrefix(rootURL, "http://"), "/"),
+		always:      direct,
+	}
+
+	return nil
+}
+
+func (t *directTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Host != t.gatewayHost {
+		return t.next.RoundTrip(request)
+	}
+
+	if t.always {
+		directRequest, err := directServiceRequest(request)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.next.RoundTrip(directRequest)
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err == nil || !errors.Is(err, syscall.ECONNREFUSED) {
+		return response, err
+	}
+
+	// the body is consumed by the first attempt, and cannot be sent again without GetBody
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return nil, err
+	}
+
+	directRequest, directErr := directServiceRequest(request)
+	if directErr != nil {
+		return nil, fmt.Errorf("%w - and fallback to the service failed: %s", err, directErr.Error())
+	}
+
+	t.fallbackOnce.Do(func() {
+		log.Printf("gateway at %s refused connection - falling back to services directly (use --%s to skip the gateway)", t.gatewayHost, FlagDirect)
+	})
+
+	directRequest = directRequest.WithContext(withRetry(directRequest.Context(), fmt.Sprintf("gateway at %s refused connection, sending to %s directly", t.gatewayHost, directRequest.URL.Host)))
+
+	return t.next.RoundTrip(directRequest)
+}
+
+// directServiceRequest clones the request, with its url pointed to the service owning the path.
+func directServiceRequest(request *http.Request) (*http.Request, error) {
+	endpoint := serviceEndpointForPath(request.URL.Path)
+	if endpoint == nil {
+		return nil, fmt.Errorf("no service is known to serve %s", request.URL.Path)
+	}
+
+	serviceURL, err := resolveServiceURL(*endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	directRequest := request.Clone(request.Context())
+	directRequest.URL.Scheme = serviceURL.Scheme
+	directRequest.URL.Host = serviceURL.Host
+	directRequest.Host = serviceURL.Host
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		directRequest.Body = body
+	}
+
+	return directRequest, nil
+}
+
+// serviceEndpointForPath returns the service serving the path behind the gateway, e.g. `/v2/app_management/...`,
+// including its OpenAPI spec at `/doc/v2/...`.
+func serviceEndpointForPath(path string) *serviceEndpoint {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "/"), "doc/")
+
+	for i, endpoint := range serviceEndpoints {
+		for _, basePath := range endpoint.BasePaths {
+			if path == basePath || strings.HasPrefix(path, basePath+"/") {
+				return &serviceEndpoints[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveServiceURL reads the listen address of the service from its runtime url file.
+func resolveServiceURL(endpoint serviceEndpoint) (*url.URL, error) {
+	urlFile := filepath.Join(constants.DefaultRuntimePath, endpoint.URLFile)
+
+	buf, err := os.ReadFile(urlFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s - is the %s service running on this host?", err.Error(), endpoint.Service)
+	}
+
+	address := strings.TrimSpace(string(buf))
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	serviceURL, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s in %s: %w", address, urlFile, err)
+	}
+
+	return serviceURL, nil
+}
+
+// directRootURL returns the root url to use for requests that do not go through the default HTTP transport,
+// e.g. websocket, which is the address of the service serving the base path in `--direct` mode.
+func directRootURL(rootURL, basePath string) (string, error) {
+	direct, err := rootCmd.PersistentFlags().GetBool(FlagDirect)
+	if err != nil || !direct {
+		return rootURL, err
+	}
+
+	endpoint := serviceEndpointForPath(basePath)
+	if endpoint == nil {
+		return rootURL, nil
+	}
+
+	serviceURL, err := resolveServiceURL(*endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	return serviceURL.Host, nil
+}
+
+// dialWithFallback calls dial with the root url to use for the base path, e.g. to open a websocket, and when the
+// gateway refuses connection, calls it again with the address of the service serving the base path, as
+// directTransport does for HTTP requests.
+func dialWithFallback(rootURL, basePath string, dial func(rootURL string) error) error {
+	directURL, err := directRootURL(rootURL, basePath)
+	if err != nil {
+		return err
+	}
+
+	err = dial(directURL)
+	if err == nil || directURL != rootURL || sshTunnel != nil || !isConnectionRefused(err) {
+		return err
+	}
+
+	endpoint := serviceEndpointForPath(basePath)
+	if endpoint == nil {
+		return err
+	}
+
+	serviceURL, directErr := resolveServiceURL(*endpoint)
+	if directErr != nil {
+		return fmt.Errorf("%w - and fallback to the service failed: %s", err, directErr.Error())
+	}
+
+	log.Printf("gateway at %s refused connection - falling back to %s directly (use --%s to skip the gateway)", rootURL, endpoint.Service, FlagDirect)
+
+	return dial(serviceURL.Host)
+}
+
+// isConnectionRefused tells if the error is caused by connection refused, including errors of clients that do not
+// wrap the underlying error, e.g. websocket.
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(err.Error(), "connection refused")
+}
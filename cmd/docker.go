@@ -0,0 +1,217 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	EnvDockerHost = "DOCKER_HOST"
+
+	DefaultDockerSocket = "/var/run/docker.sock"
+)
+
+// dockerEngine is a minimal client of the Docker Engine API, to save and load images of apps. It talks to the Docker
+// engine of the CasaOS host, i.e. the one on the remote host with --ssh.
+type dockerEngine struct {
+	client *http.Client
+}
+
+// dockerMessage is a message in the JSON stream returned when pulling or loading images.
+type dockerMessage struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+func newDockerEngine() (*dockerEngine, error) {
+	socket := DefaultDockerSocket
+
+	if host := os.Getenv(EnvDockerHost); host != "" && sshTunnel == nil {
+		path, ok := strings.CutPrefix(host, "unix://")
+		if !ok {
+			return nil, fmt.Errorf("only unix:// is supported in $%s, not %s", EnvDockerHost, host)
+		}
+
+		socket = path
+	}
+
+	dialContext := (&net.Dialer{}).DialContext
+	if sshTunnel != nil {
+		dialContext = sshTunnel.DialContext
+	}
+
+	return &dockerEngine{
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}, nil
+}
+
+func (d *dockerEngine) do(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	u := url.URL{Scheme: "http", Host: "docker", Path: path, RawQuery: query.Encode()}
+
+	request, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/x-tar")
+	}
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Docker engine - %w", err)
+	}
+
+	return response, nil
+}
+
+// dockerError builds an error from a failed response of the Docker Engine API, and closes its body.
+func dockerError(response *http.Response) error {
+	defer response.Body.Close()
+
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	return statusError(response.StatusCode, response.Status, buf, "docker")
+}
+
+// ImageID returns the ID of an image in the Docker engine, e.g. `sha256:...`, or "" if it is not found.
+func (d *dockerEngine) ImageID(ctx context.Context, image string) (string, error) {
+	response, err := d.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return "", nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", dockerError(response)
+	}
+	defer response.Body.Close()
+
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return json.Get(buf, "Id").ToString(), nil
+}
+
+// Pull pulls an image, e.g. `nginx:1.25`, to the Docker engine.
+func (d *dockerEngine) Pull(ctx context.Context, image string) error {
+	response, err := d.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {image}}, nil)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return dockerError(response)
+	}
+	defer response.Body.Close()
+
+	return readDockerMessages(response.Body)
+}
+
+// Save writes images to w as a tar archive, in the format of `docker save`.
+func (d *dockerEngine) Save(ctx context.Context, images []string, w io.Writer) error {
+	response, err := d.do(ctx, http.MethodGet, "/images/get", url.Values{"names": images}, nil)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return dockerError(response)
+	}
+	defer response.Body.Close()
+
+	_, err = io.Copy(w, response.Body)
+	return err
+}
+
+// Load loads images from a tar archive in the format of `docker save` to the Docker engine.
+func (d *dockerEngine) Load(ctx context.Context, r io.Reader) error {
+	response, err := d.do(ctx, http.MethodPost, "/images/load", url.Values{"quiet": {"1"}}, r)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return dockerError(response)
+	}
+	defer response.Body.Close()
+
+	return readDockerMessages(response.Body)
+}
+
+// readDockerMessages reads a stream of JSON messages, one per line, until the end, and returns the first error in it.
+func readDockerMessages(r io.Reader) error {
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var message dockerMessage
+			if err := json.Unmarshal(line, &message); err != nil {
+				return err
+			}
+
+			if message.Error != "" {
+				return fmt.Errorf("%s", message.Error)
+			}
+
+			if message.ErrorDetail.Message != "" {
+				return fmt.Errorf("%s", message.ErrorDetail.Message)
+			}
+
+			if stream := strings.TrimSpace(message.Stream); stream != "" {
+				log.Println(stream)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,257 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagOutput = "output"
+
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Exit codes, so that scripts wrapping this CLI can branch on failure types.
+const (
+	ExitCodeOK          = 0 // command succeeded
+	ExitCodeError       = 1 // any error not covered below
+	ExitCodeUsage       = 2 // invalid command, arguments or flags
+	ExitCodeNotFound    = 3 // HTTP 404 from the API, or not found by the CLI itself
+	ExitCodeConflict    = 4 // HTTP 409 from the API, or a conflict found by the CLI itself
+	ExitCodeUnreachable = 5 // the API cannot be reached, e.g. connection refused or timeout
+	ExitCodeAuth        = 6 // HTTP 401 or 403 from the API
+)
+
+const exitCodeHelp = `Exit codes:
+  0  success
+  1  any error not covered below
+  2  invalid command, arguments or flags
+  3  not found (HTTP 404, or e.g. an unknown user or disk)
+  4  conflict (HTTP 409, or e.g. a port already in use)
+  5  CasaOS is unreachable (e.g. connection refused or timeout)
+  6  not authorized (HTTP 401 or 403)`
+
+var errorTypes = map[int]string{
+	ExitCodeError:       "error",
+	ExitCodeUsage:       "usage",
+	ExitCodeNotFound:    "not_found",
+	ExitCodeConflict:    "conflict",
+	ExitCodeUnreachable: "unreachable",
+	ExitCodeAuth:        "auth",
+}
+
+// usageError is an error caused by invalid command, arguments or flags.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+// notFoundError is an error for something that does not exist, when it is looked up by the CLI itself rather than
+// reported by the API, e.g. a user by name.
+type notFoundError struct {
+	err error
+}
+
+func (e notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e notFoundError) Unwrap() error {
+	return e.err
+}
+
+// conflictError is an error for a change that conflicts with the current state, e.g. a port already in use, when it
+// is detected by the CLI itself rather than reported by the API.
+type conflictError struct {
+	err error
+}
+
+func (e conflictError) Error() string {
+	return e.err.Error()
+}
+
+func (e conflictError) Unwrap() error {
+	return e.err
+}
+
+// statusError builds the error for an unsuccessful response of the service, e.g. `casaos-user-service`, with the
+// message from the body.
+func statusError(statusCode int, status string, body []byte, service string) error {
+	return casaos.NewStatusError(statusCode, status, body, service)
+}
+
+// errorOutput is how an error is rendered with `--output json`
+type errorOutput struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    int    `json:"code"`
+	Type    string `json:"type"`
+	Status  int    `json:"status,omitempty"`
+	Command string `json:"command,omitempty"`
+	Message string `json:"message"`
+}
+
+// wrapUsageErrors marks errors from argument validation of the command and its subcommands as usage errors.
+func wrapUsageErrors(cmd *cobra.Command) {
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(cmd *cobra.Command, a []string) error {
+			if err := args(cmd, a); err != nil {
+				return usageError{err: err}
+			}
+			return nil
+		}
+	}
+
+	for _, c := range cmd.Commands() {
+		wrapUsageErrors(c)
+	}
+}
+
+// exitCode derives the exit code from an error, based on its HTTP status or transport failure.
+func exitCode(err error) (int, int) {
+	if err == nil {
+		return ExitCodeOK, 0
+	}
+
+	var usage usageError
+	if errors.As(err, &usage) {
+		return ExitCodeUsage, 0
+	}
+
+	message := err.Error()
+
+	// errors returned by cobra itself before any command is run
+	for _, prefix := range []string{"unknown command", "unknown flag", "unknown shorthand flag", "required flag(s)", "invalid argument", "if any flags in the group"} {
+		if strings.HasPrefix(message, prefix) {
+			return ExitCodeUsage, 0
+		}
+	}
+
+	var notFound notFoundError
+	if errors.As(err, &notFound) {
+		return ExitCodeNotFound, 0
+	}
+
+	var conflict conflictError
+	if errors.As(err, &conflict) {
+		return ExitCodeConflict, 0
+	}
+
+	var statusErr *casaos.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ExitCodeAuth, statusErr.StatusCode
+		case http.StatusNotFound:
+			return ExitCodeNotFound, statusErr.StatusCode
+		case http.StatusConflict:
+			return ExitCodeConflict, statusErr.StatusCode
+		default:
+			return ExitCodeError, statusErr.StatusCode
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		strings.Contains(message, "connection refused") { // some clients, e.g. websocket, do not wrap the underlying error
+		return ExitCodeUnreachable, 0
+	}
+
+	return ExitCodeError, 0
+}
+
+// isTransientError tells if an error is likely to go away by retrying, i.e. CasaOS is unreachable or fails with a
+// server error.
+func isTransientError(err error) bool {
+	code, status := exitCode(err)
+	return code == ExitCodeUnreachable || status >= http.StatusInternalServerError
+}
+
+// printError prints the error in the format given by `--output`, and returns the exit code for it.
+func printError(w io.Writer, cmd *cobra.Command, err error) int {
+	code, status := exitCode(err)
+
+	if outputFormat() == OutputJSON {
+		buf, _ := json.Marshal(errorOutput{
+			Error: errorDetail{
+				Code:    code,
+				Type:    errorTypes[code],
+				Status:  status,
+				Command: cmd.CommandPath(),
+				Message: err.Error(),
+			},
+		})
+
+		fmt.Fprintln(w, string(buf))
+		return code
+	}
+
+	fmt.Fprintln(w, "Error:", err.Error())
+
+	if code == ExitCodeUsage {
+		fmt.Fprintln(w)
+		fmt.Fprint(w, cmd.UsageString())
+	}
+
+	return code
+}
+
+// outputFormat returns the value of `--output`. When the command line cannot be parsed, e.g. due to an unknown
+// flag, it is the value parsed so far, or the default.
+func outputFormat() string {
+	output, err := rootCmd.PersistentFlags().GetString(FlagOutput)
+	if err != nil || output == "" {
+		return OutputText
+	}
+
+	return output
+}
+
+func validateOutput(cmd *cobra.Command) error {
+	output, err := cmd.Flags().GetString(FlagOutput)
+	if err != nil {
+		return err
+	}
+
+	if output != OutputText && output != OutputJSON {
+		return usageError{err: fmt.Errorf("invalid output format %s - should be %s or %s", output, OutputText, OutputJSON)}
+	}
+
+	return nil
+}
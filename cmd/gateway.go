@@ -16,6 +16,17 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IceWhaleTech/CasaOS-Common/external"
+	"github.com/IceWhaleTech/CasaOS-Common/model"
+	"github.com/IceWhaleTech/CasaOS-Common/utils/constants"
 	"github.com/spf13/cobra"
 )
 
@@ -26,9 +37,15 @@ var gatewayCmd = &cobra.Command{
 	GroupID: RootGroupID,
 }
 
+const (
+	FlagGatewayManagementURL = "management-url"
+)
+
 func init() {
 	rootCmd.AddCommand(gatewayCmd)
 
+	gatewayCmd.PersistentFlags().String(FlagGatewayManagementURL, "", fmt.Sprintf("url of gateway management API (default to the one in %s)", filepath.Join(constants.DefaultRuntimePath, external.ManagementURLFilename)))
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -39,3 +56,110 @@ func init() {
 	// is called directly, e.g.:
 	// gatewayCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// gatewayManagementURL returns the address of gateway management API, which is only reachable from the
+// host itself and is published by casaos-gateway to a runtime file.
+func gatewayManagementURL() (string, error) {
+	managementURL, err := gatewayCmd.PersistentFlags().GetString(FlagGatewayManagementURL)
+	if err != nil {
+		return "", err
+	}
+
+	if managementURL == "" {
+		managementURLFile := filepath.Join(constants.DefaultRuntimePath, external.ManagementURLFilename)
+
+		// the management API is dialed from the remote host with --ssh, so is the url file read there
+		var buf []byte
+		if sshTunnel != nil {
+			buf, err = sshTunnel.readFile(managementURLFile)
+		} else {
+			buf, err = os.ReadFile(managementURLFile)
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("%s - is the casaos-gateway service running on the CasaOS host?", err.Error())
+		}
+
+		managementURL = strings.TrimSpace(string(buf))
+	}
+
+	if !strings.Contains(managementURL, "://") {
+		managementURL = "http://" + managementURL
+	}
+
+	return strings.TrimRight(managementURL, "/"), nil
+}
+
+func gatewayManagementRequest(ctx context.Context, method, path string, body io.Reader) ([]byte, error) {
+	managementURL, err := gatewayManagementURL()
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, managementURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", MINEApplicationJSON)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, statusError(response.StatusCode, response.Status, buf, "casaos-gateway")
+	}
+
+	return buf, nil
+}
+
+func getGatewayPort(ctx context.Context) (string, error) {
+	buf, err := gatewayManagementRequest(ctx, http.MethodGet, external.APIGatewayPort, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// the port is either returned as is, or wrapped in `data`
+	data := json.Get(buf, "data")
+	if data.LastError() == nil {
+		return data.ToString(), nil
+	}
+
+	var port string
+	if err := json.Unmarshal(buf, &port); err != nil {
+		return strings.TrimSpace(string(buf)), nil
+	}
+
+	return port, nil
+}
+
+func getGatewayRoutes(ctx context.Context) ([]model.Route, error) {
+	buf, err := gatewayManagementRequest(ctx, http.MethodGet, external.APIGatewayRoutes, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []model.Route
+
+	data := json.Get(buf, "data")
+	if data.LastError() == nil {
+		data.ToVal(&routes)
+		return routes, data.LastError()
+	}
+
+	if err := json.Unmarshal(buf, &routes); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// gatewayRoutesListCmd represents the gatewayRoutesList command
+var gatewayRoutesListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list path prefixes registered in gateway and their upstream services",
+	Aliases: []string{"ls", "get"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		routes, err := getGatewayRoutes(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(routes) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "no route registered")
+			return nil
+		}
+
+		sort.Slice(routes, func(i, j int) bool {
+			return routes[i].Path < routes[j].Path
+		})
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "PATH\tTARGET\tSERVICE")
+		fmt.Fprintln(w, "----\t------\t-------")
+
+		for _, route := range routes {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", route.Path, route.Target, routeService(route.Path))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	gatewayRoutesCmd.AddCommand(gatewayRoutesListCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// gatewayRoutesListCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// gatewayRoutesListCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// routeService tells which `casaos-*` service owns a route, based on the base paths known to this CLI.
+func routeService(path string) string {
+	services := map[string]string{
+		BasePathAppManagement: "casaos-app-management",
+		BasePathCasaOS:        "casaos",
+		BasePathLocalStorage:  "casaos-local-storage",
+		BasePathMessageBus:    "casaos-message-bus",
+		BasePathUsers:         "casaos-user-service",
+	}
+
+	if service, ok := services[strings.Trim(path, "/")]; ok {
+		return service
+	}
+
+	return "unknown"
+}
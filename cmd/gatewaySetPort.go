@@ -0,0 +1,154 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/IceWhaleTech/CasaOS-Common/external"
+	"github.com/IceWhaleTech/CasaOS-Common/model"
+	"github.com/go-ini/ini"
+	"github.com/spf13/cobra"
+)
+
+// gatewaySetPortCmd represents the gatewaySetPort command
+var gatewaySetPortCmd = &cobra.Command{
+	Use:   "port <port>",
+	Short: "change the port gateway listens on",
+	Long: `Change the port gateway listens on via gateway management API, and make sure the new port
+is persisted in gateway config file, e.g. /etc/casaos/gateway.ini
+
+Note: CasaOS WebUI and this CLI will need to use the new port once it is changed.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(1), func(cmd *cobra.Command, args []string) error {
+		port, err := strconv.Atoi(args[0])
+		if err != nil || port < 1 || port > maxPort {
+			return fmt.Errorf("port must be a number between 1 and %d", maxPort)
+		}
+
+		return nil
+	}),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the port is also rewritten in gateway config file, which is on this host
+		if err := refuseOverSSH("changing the gateway port"); err != nil {
+			return err
+		}
+
+		force, err := cmd.Flags().GetBool(FlagForce)
+		if err != nil {
+			return err
+		}
+
+		port, err := strconv.Atoi(cmd.Flags().Arg(0))
+		if err != nil {
+			return fmt.Errorf("how can it get here?? should have been validated in cobra.MatchAll(...)")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		currentPort, err := getGatewayPort(ctx)
+		if err != nil {
+			return err
+		}
+
+		if currentPort == strconv.Itoa(port) {
+			fmt.Fprintf(cmd.OutOrStdout(), "gateway is already listening on port %d\n", port)
+			return nil
+		}
+
+		tcpInUse, _, err := portsInUse(ctx)
+		if err != nil {
+			log.Printf("unable to check if port %d is in use: %s", port, err.Error())
+		} else if tcpInUse[port] {
+			owner := "system/unknown"
+			if appPorts, err := installedAppPorts(ctx); err == nil {
+				owner = formatPortOwners(portOwners(appPorts)[portKey(port, "tcp")])
+			}
+
+			if !force {
+				return fmt.Errorf("port %d is in use by %s - use --%s to change it anyway", port, owner, FlagForce)
+			}
+
+			log.Printf("WARNING: port %d is in use by %s", port, owner)
+		}
+
+		body, err := json.Marshal(model.ChangePortRequest{Port: strconv.Itoa(port)})
+		if err != nil {
+			return err
+		}
+
+		if _, err := gatewayManagementRequest(ctx, http.MethodPut, external.APIGatewayPort, bytes.NewReader(body)); err != nil {
+			return err
+		}
+
+		if err := ensureGatewayConfigPort(port); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "gateway port changed from %s to %d\n", currentPort, port)
+
+		return nil
+	},
+}
+
+func init() {
+	gatewaySetCmd.AddCommand(gatewaySetPortCmd)
+
+	gatewaySetPortCmd.Flags().BoolP(FlagForce, "f", false, "change the port even if it is in use")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// gatewaySetPortCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// gatewaySetPortCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// ensureGatewayConfigPort validates the gateway config file and rewrites the port in it, in case
+// gateway did not persist the change by itself.
+func ensureGatewayConfigPort(port int) error {
+	if _, err := os.Stat(GatewayPath); err != nil {
+		log.Printf("gateway config file %s not found - skipping", GatewayPath)
+		return nil
+	}
+
+	cfgs, err := ini.Load(GatewayPath)
+	if err != nil {
+		return fmt.Errorf("gateway config file %s is invalid: %w", GatewayPath, err)
+	}
+
+	key := cfgs.Section("gateway").Key("port")
+	if key.Value() == strconv.Itoa(port) {
+		return nil
+	}
+
+	key.SetValue(strconv.Itoa(port))
+
+	if err := cfgs.SaveTo(GatewayPath); err != nil {
+		return fmt.Errorf("failed to update port in %s: %w", GatewayPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// gatewayShowCmd represents the gatewayShow command
+var gatewayShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show gateway port and routes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		port, err := getGatewayPort(ctx)
+		if err != nil {
+			return err
+		}
+
+		routes, err := getGatewayRoutes(ctx)
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(routes, func(i, j int) bool {
+			return routes[i].Path < routes[j].Path
+		})
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "Port:\t%s\n", port)
+		fmt.Fprintf(w, "Config:\t%s\n", GatewayPath)
+		fmt.Fprintf(w, "Routes:\t%d\n", len(routes))
+		fmt.Fprintln(w)
+
+		fmt.Fprintln(w, "PATH\tTARGET")
+		fmt.Fprintln(w, "----\t------")
+
+		for _, route := range routes {
+			fmt.Fprintf(w, "%s\t%s\n", route.Path, route.Target)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	gatewayCmd.AddCommand(gatewayShowCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// gatewayShowCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// gatewayShowCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
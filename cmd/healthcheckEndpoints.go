@@ -0,0 +1,100 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IceWhaleTech/CasaOS-Common/utils/constants"
+	"github.com/spf13/cobra"
+)
+
+const reachableTimeout = time.Second
+
+// healthcheckEndpointsCmd represents the healthcheckEndpoints command
+var healthcheckEndpointsCmd = &cobra.Command{
+	Use:   "endpoints",
+	Short: "show the address of each `casaos-*` service and the base paths it serves, as used by --direct",
+	Long: fmt.Sprintf(`Show the address of each casaos-* service and the base paths it serves, as used by --direct.
+
+Addresses are read from runtime url files in %s, which are written by each service on start,
+so this works even when the gateway is down.`, constants.DefaultRuntimePath),
+	Aliases: []string{"endpoint"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := refuseOverSSH("reading runtime url files"); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "SERVICE\tADDRESS\tREACHABLE\tBASE PATHS\tURL FILE")
+		fmt.Fprintln(w, "-------\t-------\t---------\t----------\t--------")
+
+		for _, endpoint := range serviceEndpoints {
+			address, reachable := "-", "-"
+
+			serviceURL, err := resolveServiceURL(endpoint)
+			if err != nil {
+				reachable = "no url file"
+			} else {
+				address = serviceURL.String()
+				reachable = "yes"
+
+				conn, err := net.DialTimeout("tcp", serviceURL.Host, reachableTimeout)
+				if err != nil {
+					reachable = "no"
+				} else {
+					conn.Close()
+				}
+			}
+
+			basePaths := "-"
+			if len(endpoint.BasePaths) > 0 {
+				basePaths = "/" + strings.Join(endpoint.BasePaths, ", /")
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				endpoint.Service,
+				address,
+				reachable,
+				basePaths,
+				filepath.Join(constants.DefaultRuntimePath, endpoint.URLFile),
+			)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	healthcheckCmd.AddCommand(healthcheckEndpointsCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// healthcheckEndpointsCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// healthcheckEndpointsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -16,13 +16,14 @@ limitations under the License.
 package cmd
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"fmt"
-	"net/http"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/casaos"
 	"github.com/spf13/cobra"
 )
 
@@ -31,36 +32,40 @@ var healthcheckLogsCmd = &cobra.Command{
 	Use:     "logs",
 	Short:   "get all `casaos-*` logs and save to a ZIP file",
 	Aliases: []string{"log"},
+	Long: `Get all casaos-* logs and save to a ZIP file.
+
+When the CasaOS API is unreachable, or with --local, logs are read from the journal of each casaos-* service
+on this host instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+		local, err := cmd.Flags().GetBool(FlagLocal)
 		if err != nil {
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathCasaOS)
-
-		client, err := casaos.NewClientWithResponses(url)
-		if err != nil {
-			return err
+		if local {
+			if err := refuseOverSSH("--" + FlagLocal); err != nil {
+				return err
+			}
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		fmt.Fprintln(cmd.OutOrStdout(), "getting logs...")
 
-		fmt.Println("getting logs...")
+		var logs []byte
 
-		response, err := client.GetHealthlogsWithResponse(ctx)
-		if err != nil {
-			return err
+		if !local {
+			logs, err = getHealthLogs()
+			if fallbackToLocal(err) {
+				local = true
+			} else if err != nil {
+				return err
+			}
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse casaos.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+		if local {
+			logs, err = getLocalLogs()
+			if err != nil {
+				return err
 			}
-
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
 		}
 
 		outDir, err := cmd.Flags().GetString(FlagDir)
@@ -77,20 +82,65 @@ var healthcheckLogsCmd = &cobra.Command{
 
 		zipFilePath := fmt.Sprintf("%s/casaos-%s-logs-%s.zip", outDir, Version, time.Now().Format("20060102150405"))
 
-		if err := os.WriteFile(zipFilePath, response.Body, 0o600); err != nil {
+		if err := os.WriteFile(zipFilePath, logs, 0o600); err != nil {
 			return err
 		}
 
-		fmt.Printf("logs saved to %s\n", zipFilePath)
+		fmt.Fprintf(cmd.OutOrStdout(), "logs saved to %s\n", zipFilePath)
 
 		return nil
 	},
 }
 
+// getHealthLogs gets logs of all services as a ZIP file from the CasaOS API.
+func getHealthLogs() ([]byte, error) {
+	client, err := casaosClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	return client.Health().Logs(ctx)
+}
+
+func getLocalLogs() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	services, err := listLocalServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	zipWriter := zip.NewWriter(&buf)
+
+	for _, service := range services {
+		file, err := zipWriter.Create(strings.TrimSuffix(service.Name, ".service") + ".log")
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeLocalJournal(ctx, file, service.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 func init() {
 	healthcheckCmd.AddCommand(healthcheckLogsCmd)
 
 	healthcheckLogsCmd.Flags().StringP(FlagDir, "d", "", "output directory")
+	healthcheckLogsCmd.Flags().Bool(FlagLocal, false, "read the journal on this host instead of getting logs from the CasaOS API")
 
 	// Here you will define your flags and configuration settings.
 
@@ -18,28 +18,34 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"log"
 	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/casaos"
+	"github.com/go-ini/ini"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
 
+const (
+	FlagHealthcheckApp       = "app"
+	FlagHealthcheckFreeRange = "free-range"
+)
+
 // healthcheckPortsInUseCmd represents the healthcheckPortsInUse command
 var healthcheckPortsInUseCmd = &cobra.Command{
 	Use:     "ports-in-use",
 	Short:   "get ports in use",
 	Aliases: []string{"ports", "port-in-use"},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+		appID, err := cmd.Flags().GetString(FlagHealthcheckApp)
 		if err != nil {
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathCasaOS)
-
-		client, err := casaos.NewClientWithResponses(url)
+		freeRange, err := cmd.Flags().GetString(FlagHealthcheckFreeRange)
 		if err != nil {
 			return err
 		}
@@ -47,41 +53,75 @@ var healthcheckPortsInUseCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		response, err := client.GetHealthPortsWithResponse(ctx)
+		tcpInUse, udpInUse, err := portsInUse(ctx)
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse casaos.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+		appPorts, err := installedAppPorts(ctx)
+		if err != nil {
+			// ports in use are still worth showing without attribution to compose apps
+			log.Printf("unable to get ports of installed apps: %s", err.Error())
+		}
+
+		if freeRange != "" {
+			from, to, err := parseFreeRange(freeRange)
+			if err != nil {
+				return err
 			}
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
+			used := map[int]bool{}
+			for port := range tcpInUse {
+				used[port] = true
+			}
+
+			for port := range udpInUse {
+				used[port] = true
+			}
+
+			for _, ports := range appPorts {
+				for _, p := range ports {
+					used[p.Port] = true
+				}
+			}
+
+			free := []int{}
+			for port := from; port <= to; port++ {
+				if !used[port] {
+					free = append(free, port)
+				}
+			}
+
+			if len(free) == 0 {
+				return fmt.Errorf("no free port found between %d and %d", from, to)
+			}
 
-		if response.JSON200 == nil || response.JSON200.Data == nil {
-			return fmt.Errorf("response body is empty")
+			fmt.Fprintln(cmd.OutOrStdout(), strings.Join(compressPorts(free), ", "))
+
+			return nil
 		}
 
+		owners := portOwners(appPorts)
+
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
 		defer w.Flush()
 
-		fmt.Fprintln(w, "PORT\tTYPE\t")
-		fmt.Fprintln(w, "----\t----\t")
+		fmt.Fprintln(w, "PORT\tTYPE\tOWNER")
+		fmt.Fprintln(w, "----\t----\t-----")
 
-		if response.JSON200.Data.TCP != nil {
-			sort.Ints(*response.JSON200.Data.TCP)
-			for _, port := range *response.JSON200.Data.TCP {
-				fmt.Fprintf(w, "%d\t%s\n", port, "TCP")
-			}
-		}
+		for _, protocol := range []string{"tcp", "udp"} {
+			inUse := lo.Ternary(protocol == "tcp", tcpInUse, udpInUse)
 
-		if response.JSON200.Data.UDP != nil {
-			sort.Ints(*response.JSON200.Data.UDP)
-			for _, port := range *response.JSON200.Data.UDP {
-				fmt.Fprintf(w, "%d\t%s\n", port, "UDP")
+			ports := lo.Keys(inUse)
+			sort.Ints(ports)
+
+			for _, port := range ports {
+				claimants := owners[portKey(port, protocol)]
+				if appID != "" && !lo.ContainsBy(claimants, func(o portOwner) bool { return o.AppID == appID }) {
+					continue
+				}
+
+				fmt.Fprintf(w, "%d\t%s\t%s\n", port, strings.ToUpper(protocol), formatPortOwners(claimants))
 			}
 		}
 
@@ -92,6 +132,9 @@ var healthcheckPortsInUseCmd = &cobra.Command{
 func init() {
 	healthcheckCmd.AddCommand(healthcheckPortsInUseCmd)
 
+	healthcheckPortsInUseCmd.Flags().StringP(FlagHealthcheckApp, "a", "", "only show ports published by this app")
+	healthcheckPortsInUseCmd.Flags().String(FlagHealthcheckFreeRange, "", "list unused ports within a range instead, e.g. 8000-9000")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -102,3 +145,119 @@ func init() {
 	// is called directly, e.g.:
 	// healthcheckPortsInUseCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+type portOwner struct {
+	AppID   string
+	Service string
+}
+
+func (o portOwner) String() string {
+	if o.AppID == "" {
+		return o.Service
+	}
+
+	if o.Service == "" {
+		return fmt.Sprintf("app %s", o.AppID)
+	}
+
+	return fmt.Sprintf("app %s (service %s)", o.AppID, o.Service)
+}
+
+// formatPortOwners lists all claimants of a port, or tells it is not published by any app.
+func formatPortOwners(claimants []portOwner) string {
+	if len(claimants) == 0 {
+		return "system/unknown"
+	}
+
+	return strings.Join(lo.Map(claimants, func(o portOwner, _ int) string { return o.String() }), ", ")
+}
+
+// portOwners maps each `port/protocol` to all compose apps publishing it, sorted by app id, or to the
+// casaos-gateway service for the gateway port.
+func portOwners(appPorts map[string][]publishedPort) map[string][]portOwner {
+	owners := map[string][]portOwner{}
+
+	appIDs := lo.Keys(appPorts)
+	sort.Strings(appIDs)
+
+	for _, appID := range appIDs {
+		claimed := map[string]int{}
+
+		for _, p := range appPorts[appID] {
+			key := portKey(p.Port, p.Protocol)
+
+			i, ok := claimed[key]
+			if !ok {
+				claimed[key] = len(owners[key])
+				owners[key] = append(owners[key], portOwner{AppID: appID, Service: p.Service})
+				continue
+			}
+
+			// prefer the entry that names a service over the one from `port_map`
+			if owners[key][i].Service == "" {
+				owners[key][i].Service = p.Service
+			}
+		}
+	}
+
+	if port, err := gatewayPort(); err == nil {
+		key := portKey(port, "tcp")
+		owners[key] = append([]portOwner{{Service: "casaos-gateway"}}, owners[key]...)
+	}
+
+	return owners
+}
+
+func gatewayPort() (int, error) {
+	cfgs, err := ini.Load(GatewayPath)
+	if err != nil {
+		return 0, err
+	}
+
+	return cfgs.Section("gateway").Key("port").Int()
+}
+
+func parseFreeRange(freeRange string) (int, int, error) {
+	start, end, found := strings.Cut(freeRange, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid range %s, should be in form of FROM-TO", freeRange)
+	}
+
+	from, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %s: %w", freeRange, err)
+	}
+
+	to, err := strconv.Atoi(end)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %s: %w", freeRange, err)
+	}
+
+	if from < 1 || to > maxPort || from > to {
+		return 0, 0, fmt.Errorf("invalid range %s, should be within 1-%d", freeRange, maxPort)
+	}
+
+	return from, to, nil
+}
+
+// compressPorts turns a sorted list of ports into ranges, e.g. [80 81 82 90] into ["80-82", "90"].
+func compressPorts(ports []int) []string {
+	result := []string{}
+
+	for i := 0; i < len(ports); {
+		j := i
+		for j+1 < len(ports) && ports[j+1] == ports[j]+1 {
+			j++
+		}
+
+		if i == j {
+			result = append(result, strconv.Itoa(ports[i]))
+		} else {
+			result = append(result, fmt.Sprintf("%d-%d", ports[i], ports[j]))
+		}
+
+		i = j + 1
+	}
+
+	return result
+}
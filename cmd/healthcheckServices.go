@@ -18,11 +18,10 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"strings"
 	"text/tabwriter"
+	"time"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/casaos"
 	"github.com/spf13/cobra"
 )
 
@@ -31,65 +30,96 @@ var healthcheckServicesCmd = &cobra.Command{
 	Use:     "services",
 	Short:   "get running status of each `casaos-*` service",
 	Aliases: []string{"svc", "service"},
+	Long: `Get running status of each casaos-* service.
+
+When the CasaOS API is unreachable, or with --local, the status is queried from systemd on this host instead,
+including sub-state, number of restarts and since when the service is in its current state.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+		local, err := cmd.Flags().GetBool(FlagLocal)
 		if err != nil {
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathCasaOS)
+		if local {
+			if err := refuseOverSSH("--" + FlagLocal); err != nil {
+				return err
+			}
+		}
 
-		client, err := casaos.NewClientWithResponses(url)
-		if err != nil {
-			return err
+		if !local {
+			if err := printHealthServices(cmd); !fallbackToLocal(err) {
+				return err
+			}
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		return printLocalServices(cmd)
+	},
+}
 
-		response, err := client.GetHealthServicesWithResponse(ctx)
-		if err != nil {
-			return err
-		}
+// printHealthServices prints running status of each service, as reported by the CasaOS API.
+func printHealthServices(cmd *cobra.Command) error {
+	client, err := casaosClient()
+	if err != nil {
+		return err
+	}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse casaos.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
-			}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
+	services, err := client.Health().Services(ctx)
+	if err != nil {
+		return err
+	}
 
-		if response.JSON200 == nil || response.JSON200.Data == nil {
-			return fmt.Errorf("response body is empty")
-		}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	defer w.Flush()
 
-		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
-		defer w.Flush()
+	fmt.Fprintln(w, "NAME\tSTATUS\t")
+	fmt.Fprintln(w, "----\t------\t")
 
-		fmt.Fprintln(w, "NAME\tSTATUS\t")
-		fmt.Fprintln(w, "----\t------\t")
+	for _, service := range services.Running {
+		fmt.Fprintf(w, "%s\t%s\n", strings.TrimSuffix(service, ".service"), "running")
+	}
 
-		if response.JSON200.Data.Running != nil {
-			for _, service := range *response.JSON200.Data.Running {
-				fmt.Fprintf(w, "%s\t%s\n", strings.TrimSuffix(service, ".service"), "running")
-			}
-		}
+	for _, service := range services.NotRunning {
+		fmt.Fprintf(w, "%s\t%s\n", strings.TrimSuffix(service, ".service"), "not running")
+	}
 
-		if response.JSON200.Data.NotRunning != nil {
-			for _, service := range *response.JSON200.Data.NotRunning {
-				fmt.Fprintf(w, "%s\t%s\n", strings.TrimSuffix(service, ".service"), "not running")
-			}
+	return nil
+}
+
+func printLocalServices(cmd *cobra.Command) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	services, err := listLocalServices(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "NAME\tSTATUS\tSUB-STATE\tRESTARTS\tSINCE\t")
+	fmt.Fprintln(w, "----\t------\t---------\t--------\t-----\t")
+
+	for _, service := range services {
+		since := "-"
+		if !service.Since.IsZero() {
+			since = service.Since.Format(time.RFC3339)
 		}
 
-		return nil
-	},
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", strings.TrimSuffix(service.Name, ".service"), service.ActiveState, service.SubState, service.Restarts, since)
+	}
+
+	return nil
 }
 
 func init() {
 	healthcheckCmd.AddCommand(healthcheckServicesCmd)
 
+	healthcheckServicesCmd.Flags().Bool(FlagLocal, false, "query systemd on this host instead of the CasaOS API")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
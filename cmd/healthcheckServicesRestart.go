@@ -0,0 +1,67 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// healthcheckServicesRestartCmd represents the healthcheckServicesRestart command
+var healthcheckServicesRestartCmd = &cobra.Command{
+	Use:   "restart <name>",
+	Short: "restart a `casaos-*` service via systemd on this host",
+	Long: `Restart a casaos-* service via systemd on this host, and wait for the restart to finish.
+
+The name can be given with or without the casaos- prefix, e.g. both app-management and casaos-app-management
+restart casaos-app-management.service. This works even when the CasaOS API itself is down.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := refuseOverSSH("restarting a service via systemd"); err != nil {
+			return err
+		}
+
+		unit := localServiceUnitName(args[0])
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if err := restartLocalService(ctx, unit); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s restarted\n", unit)
+
+		return nil
+	},
+}
+
+func init() {
+	healthcheckServicesCmd.AddCommand(healthcheckServicesRestartCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// healthcheckServicesRestartCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// healthcheckServicesRestartCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
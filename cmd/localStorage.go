@@ -16,6 +16,9 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
@@ -29,6 +32,10 @@ var localStorageCmd = &cobra.Command{
 const (
 	BasePathLocalStorage = "v2/local_storage"
 
+	// disks and volumes are only available in v1 API
+	PathLocalStorageDisksV1   = "v1/disks"
+	PathLocalStorageStorageV1 = "v1/storage"
+
 	FlagLocalStorageFSType            = "fstype"
 	FlagLocalStorageMountPoint        = "mount-point"
 	FlagLocalStorageSourceBasePath    = "source-base-path"
@@ -48,3 +55,43 @@ func init() {
 	// is called directly, e.g.:
 	// localStorageCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// formatBytes formats a size in bytes in human readable binary units, e.g. 1.5 GiB
+func formatBytes(size uint64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// mergedVolumes returns the mount point of the merge each source volume UUID belongs to.
+func mergedVolumes(ctx context.Context) (map[string]string, error) {
+	client, err := casaosClient()
+	if err != nil {
+		return nil, err
+	}
+
+	merges, err := client.Storage().Merges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+
+	for _, merge := range merges {
+		for _, uuid := range merge.SourceVolumeUUIDs {
+			result[uuid] = merge.MountPoint
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// localStorageAddSourceCmd represents the localStorageAddSource command
+var localStorageAddSourceCmd = &cobra.Command{
+	Use:   "add-source <mount-point> <uuid>...",
+	Short: "add source volumes to a merge",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool(FlagDryRun)
+		if err != nil {
+			return err
+		}
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		mountPoint := args[0]
+
+		merge, err := getMerge(ctx, mountPoint)
+		if err != nil {
+			return err
+		}
+
+		existing := merge.SourceVolumeUUIDs
+		sourceVolumeUUIDs := append([]string{}, existing...)
+
+		for _, uuid := range args[1:] {
+			if lo.Contains(sourceVolumeUUIDs, uuid) {
+				return fmt.Errorf("volume %s is already a source of merge at %s", uuid, mountPoint)
+			}
+
+			sourceVolumeUUIDs = append(sourceVolumeUUIDs, uuid)
+		}
+
+		merge.SourceVolumeUUIDs = sourceVolumeUUIDs
+
+		if err := setMerge(ctx, cmd.OutOrStdout(), client, *merge, existing, dryRun); err != nil {
+			return err
+		}
+
+		if !dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "merge at %s now has %d source volume(s)\n", mountPoint, len(sourceVolumeUUIDs))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	localStorageCmd.AddCommand(localStorageAddSourceCmd)
+
+	localStorageAddSourceCmd.Flags().BoolP(FlagDryRun, "d", false, "validate source volumes and show resulting capacity without changing the merge")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageAddSourceCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageAddSourceCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
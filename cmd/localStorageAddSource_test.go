@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageAddSource(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	// the merge at /DATA already includes a volume on the system disk, which must not be validated again
+	stdout, stderr, code := runCommand(t, server, "local-storage", "add-source", "/DATA", "5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9", "--dry-run")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "local_storage_add_source", stdout)
+}
+
+func TestLocalStorageAddSourceOnSystemDisk(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	_, stderr, code := runCommand(t, server, "local-storage", "add-source", "/DATA", "0e9d8c7b-6a5f-4e3d-2c1b-0a9f8e7d6c5b", "--dry-run")
+	assertExitCode(t, ExitCodeError, code, stderr)
+
+	if !strings.Contains(stderr, "is on system disk /dev/sda") {
+		t.Errorf("expected the volume to be refused as on system disk, got:\n%s", stderr)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// localStorageDeleteMergeCmd represents the localStorageDeleteMerge command
+var localStorageDeleteMergeCmd = &cobra.Command{
+	Use:   "merge <mount-point>",
+	Short: "delete a merge in local storage",
+	Long: `Delete a merge in local storage.
+
+Note: casaos-local-storage does not provide an API to delete a merge yet, so this command only checks that the
+merge exists and explains so. Use 'local-storage remove-source' to change source volumes of a merge instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		mountPoint := cmd.Flags().Arg(0)
+
+		if _, err := getMerge(ctx, mountPoint); err != nil {
+			return err
+		}
+
+		// the local storage API only lists and sets merges, and a merge cannot be set without source volumes
+		return fmt.Errorf("merge at %s is not deleted - casaos-local-storage has no API to delete a merge, use `local-storage remove-source` to change its source volumes instead", mountPoint)
+	},
+}
+
+func init() {
+	localStorageDeleteCmd.AddCommand(localStorageDeleteMergeCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageDeleteMergeCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageDeleteMergeCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,33 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "testing"
+
+func TestLocalStorageDeleteMerge(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	_, stderr, code := runCommand(t, server, "local-storage", "delete", "merge", "/DATA")
+	assertExitCode(t, ExitCodeError, code, stderr)
+	assertGolden(t, "local_storage_delete_merge", stderr)
+}
+
+func TestLocalStorageDeleteMergeNotFound(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	_, stderr, code := runCommand(t, server, "local-storage", "delete", "merge", "/mnt/unknown")
+	assertExitCode(t, ExitCodeNotFound, code, stderr)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// localStorageListDisksCmd represents the localStorageListDisks command
+var localStorageListDisksCmd = &cobra.Command{
+	Use:     "disks",
+	Short:   "list disks in local storage",
+	Aliases: []string{"disk"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		disks, err := getDisks(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(disks) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No disk found")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintln(w, "PATH\tMODEL\tSIZE\tTYPE\tHEALTH\tSERIAL")
+		fmt.Fprintln(w, "----\t-----\t----\t----\t------\t------")
+
+		for _, disk := range disks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				disk.Path,
+				disk.Model,
+				formatBytes(disk.Size),
+				disk.Type,
+				disk.Health,
+				disk.Serial,
+			)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	localStorageListCmd.AddCommand(localStorageListDisksCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageListDisksCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageListDisksCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+type storageDisk struct {
+	Name        string
+	Path        string
+	Model       string
+	Serial      string
+	Type        string
+	Health      string
+	Temperature int
+	Size        uint64
+}
+
+func getDisks(ctx context.Context) ([]storageDisk, error) {
+	buf, err := v1Request(ctx, http.MethodGet, PathLocalStorageDisksV1, "", nil, "casaos-local-storage")
+	if err != nil {
+		return nil, err
+	}
+
+	data := json.Get(buf, "data")
+
+	disks := make([]storageDisk, 0, data.Size())
+	for i := 0; i < data.Size(); i++ {
+		disk := data.Get(i)
+
+		disks = append(disks, storageDisk{
+			Name:        disk.Get("name").ToString(),
+			Path:        disk.Get("path").ToString(),
+			Model:       disk.Get("model").ToString(),
+			Serial:      disk.Get("serial").ToString(),
+			Type:        disk.Get("disk_type").ToString(),
+			Health:      disk.Get("health").ToString(),
+			Temperature: disk.Get("temperature").ToInt(),
+			Size:        disk.Get("size").ToUint64(),
+		})
+	}
+
+	sort.Slice(disks, func(i, j int) bool {
+		return disks[i].Path < disks[j].Path
+	})
+
+	return disks, nil
+}
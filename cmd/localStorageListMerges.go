@@ -18,47 +18,33 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/local_storage"
 )
 
 // localStorageListMergesCmd represents the localStorageListMerges command
 var localStorageListMergesCmd = &cobra.Command{
 	Use:   "merges",
 	Short: "list merges in local storage",
-	Run: func(cmd *cobra.Command, args []string) {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathLocalStorage)
-
-		client, err := local_storage.NewClientWithResponses(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := casaosClient()
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.GetMergesWithResponse(ctx, &local_storage.GetMergesParams{})
+		merges, err := client.Storage().Merges(ctx)
 		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		if response.StatusCode() != http.StatusOK {
-			log.Fatalln("unexpected status code", response.Status())
+			return err
 		}
 
-		if response.JSON200.Data == nil || len(*response.JSON200.Data) == 0 {
-			return
+		if len(merges) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No merge found")
+			return nil
 		}
 
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
@@ -67,16 +53,18 @@ var localStorageListMergesCmd = &cobra.Command{
 		fmt.Fprintln(w, "FSTYPE\tMOUNT_POINT\tSOURCE_BASE_PATH\tSOURCE_VOLUME_UUIDS\tCREATED_AT\tUPDATED_AT")
 		fmt.Fprintln(w, "------\t-----------\t----------------\t-------------------\t----------\t----------")
 
-		for _, merge := range *response.JSON200.Data {
+		for _, merge := range merges {
 			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-				*merge.Fstype,
+				valueOrDash(merge.FSType),
 				merge.MountPoint,
-				*merge.SourceBasePath,
-				strings.Join(*merge.SourceVolumeUuids, ","),
+				valueOrDash(merge.SourceBasePath),
+				strings.Join(merge.SourceVolumeUUIDs, ","),
 				merge.CreatedAt,
 				merge.UpdatedAt,
 			)
 		}
+
+		return nil
 	},
 }
 
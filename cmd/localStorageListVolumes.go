@@ -0,0 +1,147 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// localStorageListVolumesCmd represents the localStorageListVolumes command
+var localStorageListVolumesCmd = &cobra.Command{
+	Use:     "volumes",
+	Short:   "list volumes in local storage, e.g. to find UUIDs for `set merge`",
+	Aliases: []string{"volume", "partitions", "partition"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		volumes, err := getVolumes(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(volumes) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No volume found")
+			return nil
+		}
+
+		merged, err := mergedVolumes(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		printVolumes(w, volumes, merged)
+
+		return nil
+	},
+}
+
+func init() {
+	localStorageListCmd.AddCommand(localStorageListVolumesCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageListVolumesCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageListVolumesCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+type storageVolume struct {
+	UUID       string
+	Label      string
+	FSType     string
+	Path       string
+	DiskPath   string
+	MountPoint string
+	Size       uint64
+	Avail      uint64
+}
+
+func getVolumes(ctx context.Context) ([]storageVolume, error) {
+	buf, err := v1Request(ctx, http.MethodGet, PathLocalStorageStorageV1, "", nil, "casaos-local-storage")
+	if err != nil {
+		return nil, err
+	}
+
+	data := json.Get(buf, "data")
+
+	volumes := []storageVolume{}
+	for i := 0; i < data.Size(); i++ {
+		disk := data.Get(i)
+
+		children := disk.Get("children")
+		for j := 0; j < children.Size(); j++ {
+			volume := children.Get(j)
+
+			volumes = append(volumes, storageVolume{
+				UUID:       volume.Get("uuid").ToString(),
+				Label:      volume.Get("label").ToString(),
+				FSType:     volume.Get("type").ToString(),
+				Path:       volume.Get("path").ToString(),
+				DiskPath:   disk.Get("path").ToString(),
+				MountPoint: volume.Get("mount_point").ToString(),
+				Size:       volume.Get("size").ToUint64(),
+				Avail:      volume.Get("avail").ToUint64(),
+			})
+		}
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Path < volumes[j].Path
+	})
+
+	return volumes, nil
+}
+
+func printVolumes(w *tabwriter.Writer, volumes []storageVolume, merged map[string]string) {
+	fmt.Fprintln(w, "UUID\tLABEL\tFSTYPE\tSIZE\tUSED\tMOUNT_POINT\tMERGE")
+	fmt.Fprintln(w, "----\t-----\t------\t----\t----\t-----------\t-----")
+
+	for _, volume := range volumes {
+		used := uint64(0)
+		if volume.Size > volume.Avail {
+			used = volume.Size - volume.Avail
+		}
+
+		merge := "-"
+		if mountPoint, ok := merged[volume.UUID]; ok {
+			merge = mountPoint
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			volume.UUID,
+			volume.Label,
+			volume.FSType,
+			formatBytes(volume.Size),
+			formatBytes(used),
+			volume.MountPoint,
+			merge,
+		)
+	}
+}
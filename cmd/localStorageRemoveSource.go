@@ -0,0 +1,94 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// localStorageRemoveSourceCmd represents the localStorageRemoveSource command
+var localStorageRemoveSourceCmd = &cobra.Command{
+	Use:   "remove-source <mount-point> <uuid>...",
+	Short: "remove source volumes from a merge",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool(FlagDryRun)
+		if err != nil {
+			return err
+		}
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		mountPoint := args[0]
+
+		merge, err := getMerge(ctx, mountPoint)
+		if err != nil {
+			return err
+		}
+
+		existing := merge.SourceVolumeUUIDs
+
+		for _, uuid := range args[1:] {
+			if !lo.Contains(existing, uuid) {
+				return fmt.Errorf("volume %s is not a source of merge at %s", uuid, mountPoint)
+			}
+		}
+
+		sourceVolumeUUIDs := lo.Without(existing, args[1:]...)
+
+		if len(sourceVolumeUUIDs) == 0 {
+			return fmt.Errorf("merge at %s needs at least one source volume, refusing to remove all of them", mountPoint)
+		}
+
+		merge.SourceVolumeUUIDs = sourceVolumeUUIDs
+
+		if err := setMerge(ctx, cmd.OutOrStdout(), client, *merge, existing, dryRun); err != nil {
+			return err
+		}
+
+		if !dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "merge at %s now has %d source volume(s)\n", mountPoint, len(sourceVolumeUUIDs))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	localStorageCmd.AddCommand(localStorageRemoveSourceCmd)
+
+	localStorageRemoveSourceCmd.Flags().BoolP(FlagDryRun, "d", false, "validate source volumes and show resulting capacity without changing the merge")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageRemoveSourceCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageRemoveSourceCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
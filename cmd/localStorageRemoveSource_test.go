@@ -0,0 +1,34 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "testing"
+
+func TestLocalStorageRemoveSource(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "local-storage", "remove-source", "/DATA", "c1d2e3f4-a5b6-4c7d-8e9f-0a1b2c3d4e5f", "--dry-run")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "local_storage_remove_source", stdout)
+}
+
+func TestLocalStorageRemoveAllSources(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	_, stderr, code := runCommand(t, server, "local-storage", "remove-source", "/DATA", "c1d2e3f4-a5b6-4c7d-8e9f-0a1b2c3d4e5f", "7a4d6c2e-1b3f-4e5a-9c8d-0f1e2d3c4b5a", "--dry-run")
+	assertExitCode(t, ExitCodeError, code, stderr)
+	assertGolden(t, "local_storage_remove_all_sources", stderr)
+}
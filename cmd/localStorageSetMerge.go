@@ -18,12 +18,13 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
 	"strings"
+	"text/tabwriter"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/local_storage"
-	"github.com/IceWhaleTech/CasaOS-Common/utils"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
 
@@ -31,79 +32,173 @@ import (
 var localStorageSetMergeCmd = &cobra.Command{
 	Use:   "merge",
 	Short: "set a merge in local storage",
-	Run: func(cmd *cobra.Command, args []string) {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathLocalStorage)
-
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fsType, err := cmd.Flags().GetString(FlagLocalStorageFSType)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		mountPoint, err := cmd.Flags().GetString(FlagLocalStorageMountPoint)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		sourceBasePath, err := cmd.Flags().GetString(FlagLocalStorageSourceBasePath)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		sourceVolumeUUIDs, err := cmd.Flags().GetString(FlagLocalStorageSourceVolumeUUIDs)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
+		}
+
+		dryRun, err := cmd.Flags().GetBool(FlagDryRun)
+		if err != nil {
+			return err
 		}
 
-		client, err := local_storage.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		request := local_storage.Merge{
-			Fstype:     &fsType,
-			MountPoint: mountPoint,
-		}
-
-		if sourceBasePath != "" {
-			request.SourceBasePath = &sourceBasePath
+		request := casaos.Merge{
+			FSType:         fsType,
+			MountPoint:     mountPoint,
+			SourceBasePath: sourceBasePath,
 		}
 
 		if sourceVolumeUUIDs != "" {
-			request.SourceVolumeUuids = utils.Ptr(strings.Split(sourceVolumeUUIDs, ","))
-		}
-
-		response, err := client.SetMergeWithResponse(ctx, request)
-		if err != nil {
-			log.Fatalln(err.Error())
+			request.SourceVolumeUUIDs = strings.Split(sourceVolumeUUIDs, ",")
 		}
 
-		if response == nil {
-			log.Fatalln("empty response")
+		// source volumes of an existing merge at the mount point are kept as they are
+		existing := []string{}
+		if merge, err := client.Storage().Merge(ctx, mountPoint); err != nil {
+			return err
+		} else if merge != nil {
+			existing = merge.SourceVolumeUUIDs
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			log.Fatalln("unexpected status code", response.Status())
-		}
+		return setMerge(ctx, cmd.OutOrStdout(), client, request, existing, dryRun)
 	},
 }
 
 func init() {
 	localStorageSetCmd.AddCommand(localStorageSetMergeCmd)
 
-	localStorageSetMergeCmd.Flags().StringP(FlagLocalStorageFSType, "t", "fuse.mergerfs", "merge type")
+	localStorageSetMergeCmd.Flags().StringP(FlagLocalStorageFSType, "t", "", "merge type (default is decided by casaos-local-storage)")
 	localStorageSetMergeCmd.Flags().StringP(FlagLocalStorageMountPoint, "m", "", "mount point")
 	localStorageSetMergeCmd.Flags().String(FlagLocalStorageSourceBasePath, "", "source base path")
-	localStorageSetMergeCmd.Flags().String(FlagLocalStorageSourceVolumeUUIDs, "", "source volume uuids (separated by comma)")
+	localStorageSetMergeCmd.Flags().String(FlagLocalStorageSourceVolumeUUIDs, "", "source volume uuids (separated by comma, see 'local-storage list volumes')")
+	localStorageSetMergeCmd.Flags().BoolP(FlagDryRun, "d", false, "validate source volumes and show resulting capacity without setting the merge")
 
 	if err := localStorageSetMergeCmd.MarkFlagRequired(FlagLocalStorageMountPoint); err != nil {
 		log.Fatalln(err.Error())
 	}
 }
+
+// setMerge validates source volumes added to the merge, i.e. those not in existing, and sets the merge unless it is a
+// dry run. Existing source volumes are not validated again, e.g. the system disk already in a default /DATA merge.
+func setMerge(ctx context.Context, writer io.Writer, client *casaos.Client, merge casaos.Merge, existing []string, dryRun bool) error {
+	if len(merge.SourceVolumeUUIDs) == 0 {
+		return fmt.Errorf("at least one source volume is required - use `local-storage list volumes` to see all volumes")
+	}
+
+	volumes, err := getVolumes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := validateMergeSources(volumes, lo.Without(merge.SourceVolumeUUIDs, existing...)); err != nil {
+		return err
+	}
+
+	if dryRun {
+		printMergeSources(writer, merge.MountPoint, lo.Filter(volumes, func(v storageVolume, _ int) bool {
+			return lo.Contains(merge.SourceVolumeUUIDs, v.UUID)
+		}))
+		return nil
+	}
+
+	_, err = client.Storage().SetMerge(ctx, merge)
+	return err
+}
+
+// validateMergeSources makes sure each of the source volumes exists, is mounted, and is not on the system disk.
+func validateMergeSources(volumes []storageVolume, sourceVolumeUUIDs []string) error {
+	systemDisks := map[string]bool{}
+	for _, volume := range volumes {
+		if volume.MountPoint == "/" || volume.MountPoint == "/boot" {
+			systemDisks[volume.DiskPath] = true
+		}
+	}
+
+	for _, uuid := range sourceVolumeUUIDs {
+		volume, found := lo.Find(volumes, func(v storageVolume) bool {
+			return v.UUID == uuid
+		})
+
+		if !found {
+			return notFoundError{err: fmt.Errorf("volume %s not found - use `local-storage list volumes` to see all volumes", uuid)}
+		}
+
+		if volume.MountPoint == "" {
+			return fmt.Errorf("volume %s (%s) is not mounted", uuid, volume.Path)
+		}
+
+		if systemDisks[volume.DiskPath] {
+			return fmt.Errorf("volume %s (%s) is on system disk %s, which cannot be merged", uuid, volume.Path, volume.DiskPath)
+		}
+	}
+
+	return nil
+}
+
+func printMergeSources(writer io.Writer, mountPoint string, sources []storageVolume) {
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "UUID\tPATH\tMOUNT_POINT\tSIZE\tAVAIL")
+	fmt.Fprintln(w, "----\t----\t-----------\t----\t-----")
+
+	size, avail := uint64(0), uint64(0)
+	for _, source := range sources {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			source.UUID,
+			source.Path,
+			source.MountPoint,
+			formatBytes(source.Size),
+			formatBytes(source.Avail),
+		)
+
+		size += source.Size
+		avail += source.Avail
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "(dry run) %s would be a pool of %d volume(s) with capacity %s (%s available)\n", mountPoint, len(sources), formatBytes(size), formatBytes(avail))
+}
+
+// getMerge returns the merge at the mount point.
+func getMerge(ctx context.Context, mountPoint string) (*casaos.Merge, error) {
+	client, err := casaosClient()
+	if err != nil {
+		return nil, err
+	}
+
+	merge, err := client.Storage().Merge(ctx, mountPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if merge != nil {
+		return merge, nil
+	}
+
+	return nil, notFoundError{err: fmt.Errorf("merge at %s not found - use `local-storage list merges` to see all merges", mountPoint)}
+}
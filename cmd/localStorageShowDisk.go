@@ -0,0 +1,99 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// localStorageShowDiskCmd represents the localStorageShowDisk command
+var localStorageShowDiskCmd = &cobra.Command{
+	Use:   "disk <path>",
+	Short: "show information of a disk and its volumes, e.g. /dev/sdb",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		path := cmd.Flags().Arg(0)
+
+		disks, err := getDisks(ctx)
+		if err != nil {
+			return err
+		}
+
+		disk, found := lo.Find(disks, func(d storageDisk) bool {
+			return d.Path == path || d.Name == path
+		})
+		if !found {
+			return notFoundError{err: fmt.Errorf("disk %s not found - use `local-storage list disks` to see all disks", path)}
+		}
+
+		volumes, err := getVolumes(ctx)
+		if err != nil {
+			return err
+		}
+
+		volumes = lo.Filter(volumes, func(v storageVolume, _ int) bool {
+			return v.DiskPath == disk.Path
+		})
+
+		merged, err := mergedVolumes(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "Path:\t%s\n", disk.Path)
+		fmt.Fprintf(w, "Model:\t%s\n", disk.Model)
+		fmt.Fprintf(w, "Serial:\t%s\n", disk.Serial)
+		fmt.Fprintf(w, "Type:\t%s\n", disk.Type)
+		fmt.Fprintf(w, "Size:\t%s\n", formatBytes(disk.Size))
+		fmt.Fprintf(w, "Health:\t%s\n", disk.Health)
+		fmt.Fprintf(w, "Temperature:\t%d°C\n", disk.Temperature)
+		fmt.Fprintln(w)
+
+		if len(volumes) == 0 {
+			fmt.Fprintln(w, "No volume found on this disk")
+			return nil
+		}
+
+		printVolumes(w, volumes, merged)
+
+		return nil
+	},
+}
+
+func init() {
+	localStorageShowCmd.AddCommand(localStorageShowDiskCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageShowDiskCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageShowDiskCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,416 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagLocalStorageTop = "top"
+
+	DefaultDataPath = "/DATA"
+)
+
+// localStorageUsageCmd represents the localStorageUsage command
+var localStorageUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "report storage used by each app and free space of each mount",
+	Long: `Report bytes used by bind-mount volumes declared in compose of each installed app, and free space
+of each merge mount point (and ` + DefaultDataPath + `).
+
+Sizes are counted once for volumes nested in or sharing the same source, without crossing into other file systems
+(e.g. /proc, /sys, or disks mounted below the source) - unless a disk is mounted at the source of another volume, which
+is then counted on its own.
+
+This command reads the file system directly, so it has to be run on the CasaOS host, usually as root. Use the global
+--output json flag for a JSON report.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := refuseOverSSH("reading the file system"); err != nil {
+			return err
+		}
+
+		top, err := cmd.Flags().GetInt(FlagLocalStorageTop)
+		if err != nil {
+			return err
+		}
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		volumes, err := appVolumes(ctx, client)
+		if err != nil {
+			return err
+		}
+
+		mountPoints, err := mergeMountPoints(ctx, client)
+		if err != nil {
+			return err
+		}
+
+		report := storageUsageReport{
+			Apps:   []appUsage{},
+			Mounts: []mountUsage{},
+		}
+
+		sizes := directorySizes(lo.Map(volumes, func(volume volumeUsage, _ int) string { return volume.Source }))
+
+		apps := map[string]*appUsage{}
+		for _, volume := range volumes {
+			size := sizes[volume.Source]
+			volume.Used, volume.Partial = size.used, size.partial
+
+			app, ok := apps[volume.AppID]
+			if !ok {
+				app = &appUsage{ID: volume.AppID, Volumes: []volumeUsage{}}
+				apps[volume.AppID] = app
+			}
+
+			// a volume within another volume of the same app is already counted, and vice versa
+			volume.Nested = lo.ContainsBy(app.Volumes, func(v volumeUsage) bool {
+				return !v.Nested && isWithinPath(volume.Source, v.Source)
+			})
+
+			if !volume.Nested {
+				for i, v := range app.Volumes {
+					if !v.Nested && isWithinPath(v.Source, volume.Source) {
+						app.Used -= v.Used
+						app.Volumes[i].Nested = true
+					}
+				}
+
+				app.Used += volume.Used
+			}
+
+			app.Volumes = append(app.Volumes, volume)
+		}
+
+		for _, app := range apps {
+			sort.Slice(app.Volumes, func(i, j int) bool {
+				return app.Volumes[i].Used > app.Volumes[j].Used
+			})
+
+			report.Apps = append(report.Apps, *app)
+		}
+
+		sort.Slice(report.Apps, func(i, j int) bool {
+			return report.Apps[i].Used > report.Apps[j].Used
+		})
+
+		if top > 0 && len(report.Apps) > top {
+			report.Apps = report.Apps[:top]
+		}
+
+		for _, mountPoint := range mountPoints {
+			var stat syscall.Statfs_t
+			if err := syscall.Statfs(mountPoint, &stat); err != nil {
+				continue
+			}
+
+			size := stat.Blocks * uint64(stat.Bsize)
+			free := stat.Bavail * uint64(stat.Bsize)
+
+			report.Mounts = append(report.Mounts, mountUsage{
+				MountPoint: mountPoint,
+				Size:       size,
+				Used:       size - stat.Bfree*uint64(stat.Bsize),
+				Free:       free,
+			})
+		}
+
+		if outputFormat() == OutputJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+
+		printStorageUsageReport(cmd.OutOrStdout(), report)
+
+		return nil
+	},
+}
+
+func init() {
+	localStorageCmd.AddCommand(localStorageUsageCmd)
+
+	localStorageUsageCmd.Flags().IntP(FlagLocalStorageTop, "n", 0, "only show the top N apps using the most storage")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageUsageCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageUsageCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+type volumeUsage struct {
+	AppID   string `json:"-"`
+	Service string `json:"service"`
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Used    uint64 `json:"used"`
+	Partial bool   `json:"partial,omitempty"`
+	Nested  bool   `json:"nested,omitempty"` // within another volume of the app, so not counted again in its total
+}
+
+type appUsage struct {
+	ID      string        `json:"id"`
+	Used    uint64        `json:"used"`
+	Volumes []volumeUsage `json:"volumes"`
+}
+
+type mountUsage struct {
+	MountPoint string `json:"mount_point"`
+	Size       uint64 `json:"size"`
+	Used       uint64 `json:"used"`
+	Free       uint64 `json:"free"`
+}
+
+type storageUsageReport struct {
+	Apps   []appUsage   `json:"apps"`
+	Mounts []mountUsage `json:"mounts"`
+}
+
+// appVolumes returns bind-mount volumes declared in compose of each installed app.
+func appVolumes(ctx context.Context, client *casaos.Client) ([]volumeUsage, error) {
+	apps, err := client.Apps().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []volumeUsage{}
+
+	for _, app := range apps {
+		for _, service := range app.Services {
+			for _, volume := range service.Volumes {
+				if volume.Type != "bind" {
+					continue
+				}
+
+				volumes = append(volumes, volumeUsage{
+					AppID:   app.ID,
+					Service: service.Name,
+					Source:  filepath.Clean(volume.Source),
+					Target:  volume.Target,
+				})
+			}
+		}
+	}
+
+	return volumes, nil
+}
+
+// mergeMountPoints returns mount points of all merges, plus the default data path if it exists.
+func mergeMountPoints(ctx context.Context, client *casaos.Client) ([]string, error) {
+	merges, err := client.Storage().Merges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mountPoints := lo.Map(merges, func(merge casaos.Merge, _ int) string { return merge.MountPoint })
+
+	if _, err := os.Stat(DefaultDataPath); err == nil && !lo.Contains(mountPoints, DefaultDataPath) {
+		mountPoints = append(mountPoints, DefaultDataPath)
+	}
+
+	sort.Strings(mountPoints)
+
+	return mountPoints, nil
+}
+
+// pseudoFileSystems are never walked, as their sizes are not real usage and reading them can block.
+var pseudoFileSystems = []string{"/proc", "/sys"}
+
+type directoryUsage struct {
+	used    uint64
+	partial bool
+}
+
+// directorySizes returns total size of regular files under each path, keyed by the cleaned path. Each file is read
+// once, even if paths are duplicated or nested in each other. Each path is walked on its own file system, i.e. a path
+// nested in another one but on a different disk is still counted, in both of them, while other file systems mounted
+// below a path are not. A result is partial if some files could not be read, e.g. due to permission.
+func directorySizes(paths []string) map[string]directoryUsage {
+	paths = lo.Uniq(lo.Map(paths, func(path string, _ int) string { return filepath.Clean(path) }))
+
+	// size of files under each path, excluding those under other paths nested in it, which are walked separately
+	own := map[string]directoryUsage{}
+	for _, root := range paths {
+		nested := func(path string) bool {
+			return path != root && lo.Contains(paths, path)
+		}
+
+		usage := directoryUsage{}
+		walkDirectory(root, nested, func(path string, size uint64, err error) {
+			if err != nil {
+				usage.partial = true
+			} else {
+				usage.used += size
+			}
+		})
+
+		own[root] = usage
+	}
+
+	result := map[string]directoryUsage{}
+	for _, path := range paths {
+		usage := directoryUsage{}
+		for _, p := range paths {
+			if isWithinPath(p, path) {
+				usage.used += own[p].used
+				usage.partial = usage.partial || own[p].partial
+			}
+		}
+
+		result[path] = usage
+	}
+
+	return result
+}
+
+// walkDirectory calls fn with the size of each regular file under root, or with the error if a file cannot be read.
+// It does not cross into other file systems, skips pseudo file systems, and skips directories for which skip is true.
+func walkDirectory(root string, skip func(path string) bool, fn func(path string, size uint64, err error)) {
+	if lo.ContainsBy(pseudoFileSystems, func(p string) bool { return isWithinPath(root, p) }) {
+		return
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		fn(root, 0, err)
+		return
+	}
+
+	device := deviceOf(info)
+
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fn(path, 0, err)
+			return nil
+		}
+
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+
+			if skip(path) || lo.Contains(pseudoFileSystems, path) {
+				return filepath.SkipDir
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				fn(path, 0, err)
+				return filepath.SkipDir
+			}
+
+			if deviceOf(info) != device {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			fn(path, 0, err)
+			return nil
+		}
+
+		fn(path, uint64(info.Size()), nil)
+
+		return nil
+	}); err != nil {
+		fn(root, 0, err)
+	}
+}
+
+// deviceOf returns the ID of the device containing the file.
+func deviceOf(info fs.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev)
+	}
+
+	return 0
+}
+
+// isWithinPath tells if path is the same as, or under parent. Both paths should be cleaned.
+func isWithinPath(path, parent string) bool {
+	if parent == "/" {
+		return strings.HasPrefix(path, "/")
+	}
+
+	return path == parent || strings.HasPrefix(path, parent+"/")
+}
+
+func printStorageUsageReport(writer io.Writer, report storageUsageReport) {
+	w := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "APP\tSERVICE\tSOURCE\tTARGET\tUSED")
+	fmt.Fprintln(w, "---\t-------\t------\t------\t----")
+
+	for _, app := range report.Apps {
+		fmt.Fprintf(w, "%s\t\t\t\t%s\n", app.ID, formatBytes(app.Used))
+
+		for _, volume := range app.Volumes {
+			used := formatBytes(volume.Used)
+			if volume.Partial {
+				used += " (partial)"
+			}
+
+			if volume.Nested {
+				used += " (nested)"
+			}
+
+			fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\n", volume.Service, volume.Source, volume.Target, used)
+		}
+	}
+
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "MOUNT_POINT\tSIZE\tUSED\tFREE")
+	fmt.Fprintln(w, "-----------\t----\t----\t----")
+
+	for _, mount := range report.Mounts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mount.MountPoint, formatBytes(mount.Size), formatBytes(mount.Used), formatBytes(mount.Free))
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirectorySizes(t *testing.T) {
+	root := t.TempDir()
+
+	appData := filepath.Join(root, "AppData")
+	media := filepath.Join(appData, "media")
+
+	for path, size := range map[string]int{
+		filepath.Join(appData, "config.yml"): 3,
+		filepath.Join(media, "movie.mkv"):    5,
+		filepath.Join(root, "other.txt"):     7,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a nested source is walked on its own, and counted in the source it is nested in as well, but only once
+	sizes := directorySizes([]string{appData, media + "/", appData})
+
+	for path, used := range map[string]uint64{appData: 8, media: 5} {
+		if sizes[path].used != used || sizes[path].partial {
+			t.Errorf("expected %s to use %d bytes, got %+v", path, used, sizes[path])
+		}
+	}
+
+	if len(sizes) != 2 {
+		t.Errorf("expected sizes of 2 paths, got %+v", sizes)
+	}
+}
+
+func TestDirectorySizesNotFound(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+
+	if usage := directorySizes([]string{path})[path]; usage.used != 0 || !usage.partial {
+		t.Errorf("expected a missing path to be partial, got %+v", usage)
+	}
+}
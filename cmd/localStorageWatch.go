@@ -0,0 +1,232 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagLocalStorageExec          = "exec"
+	FlagLocalStorageExecQueueSize = "exec-queue-size"
+
+	SourceIDLocalStorage = "local-storage"
+)
+
+// localStorageWatchCmd represents the localStorageWatch command
+var localStorageWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "watch disk and mount events of local storage",
+	Long: `Watch disk and mount events published by local storage to message bus, and show them as a
+human readable timeline.
+
+With --exec, the command is run via 'sh -c' for each event, with the event in JSON format as stdin, and
+following environment variables:
+
+  CASAOS_EVENT_SOURCE_ID   source id of the event, i.e. local-storage
+  CASAOS_EVENT_NAME        name of the event, e.g. local-storage:disk:added
+  CASAOS_EVENT_<PROPERTY>  each property of the event, e.g. CASAOS_EVENT_LOCAL_STORAGE_PATH
+
+The command runs in the background, one event at a time. Events arriving while --exec-queue-size events are
+already waiting are shown but skip the command.`,
+	Example: `
+$ casaos-cli local-storage watch --exec 'logger -t casaos "$CASAOS_EVENT_NAME"'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+		if err != nil {
+			return err
+		}
+
+		hook, err := cmd.Flags().GetString(FlagLocalStorageExec)
+		if err != nil {
+			return err
+		}
+
+		bufferSize, err := cmd.Flags().GetUint(FlagMessageBusMessageBufferSize)
+		if err != nil {
+			return err
+		}
+
+		queueSize, err := cmd.Flags().GetUint(FlagLocalStorageExecQueueSize)
+		if err != nil {
+			return err
+		}
+
+		// hooks run one at a time in the background, so a slow hook does not hold up receiving events
+		hooks := make(chan casaos.Event, queueSize)
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for event := range hooks {
+				// a failing hook should not stop watching
+				if err := runEventHook(hook, event, cmd.OutOrStdout(), cmd.ErrOrStderr()); err != nil {
+					log.Printf("hook failed for event %s: %s", event.Name, err.Error())
+				}
+			}
+		}()
+
+		defer func() {
+			close(hooks)
+			<-done
+		}()
+
+		return subscribeWSEvents(rootURL, "event", SourceIDLocalStorage, "", bufferSize, func(event casaos.Event) error {
+			timestamp := time.Now()
+			if event.Timestamp != nil {
+				timestamp = *event.Timestamp
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s  %s\n", timestamp.Local().Format(time.RFC3339), describeStorageEvent(event))
+
+			if hook == "" {
+				return nil
+			}
+
+			select {
+			case hooks <- event:
+			default:
+				log.Printf("hook queue is full, skipping hook for event %s", event.Name)
+			}
+
+			return nil
+		})
+	},
+}
+
+func init() {
+	localStorageCmd.AddCommand(localStorageWatchCmd)
+
+	localStorageWatchCmd.Flags().StringP(FlagLocalStorageExec, "e", "", "command to run for each event")
+	localStorageWatchCmd.Flags().Uint(FlagLocalStorageExecQueueSize, 64, "maximum number of events waiting for the command, more events are skipped")
+	localStorageWatchCmd.Flags().UintP(FlagMessageBusMessageBufferSize, "m", 1024, "message buffer size")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// localStorageWatchCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// localStorageWatchCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+// describeStorageEvent renders an event like `local-storage:disk:added` as e.g.
+// "disk /dev/sdb added: 3.6 TiB WDC WD40EFRX, UUID ..."
+func describeStorageEvent(event casaos.Event) string {
+	properties := map[string]string{}
+	for key, value := range event.Properties {
+		// properties are usually prefixed with source id, e.g. `local-storage:path`
+		properties[key[strings.LastIndex(key, ":")+1:]] = value
+	}
+
+	subject, action := "", strings.TrimPrefix(event.Name, SourceIDLocalStorage+":")
+	if parts := strings.Split(action, ":"); len(parts) >= 2 {
+		subject, action = strings.Join(parts[:len(parts)-1], " "), parts[len(parts)-1]
+	}
+
+	path := firstNonEmpty(properties, "path", "mount_point", "name")
+
+	line := strings.TrimSpace(fmt.Sprintf("%s %s %s", subject, path, action))
+
+	details := []string{}
+
+	size := firstNonEmpty(properties, "size")
+	if n, err := strconv.ParseUint(size, 10, 64); err == nil {
+		size = formatBytes(n)
+	}
+
+	if description := strings.TrimSpace(strings.Join([]string{size, firstNonEmpty(properties, "vendor"), firstNonEmpty(properties, "model")}, " ")); description != "" {
+		details = append(details, description)
+	}
+
+	if uuid := firstNonEmpty(properties, "uuid"); uuid != "" {
+		details = append(details, "UUID "+uuid)
+	}
+
+	if mountPoint := firstNonEmpty(properties, "mount_point"); mountPoint != "" && mountPoint != path {
+		details = append(details, "mounted at "+mountPoint)
+	}
+
+	if len(details) == 0 {
+		// unknown event - show all properties as is
+		keys := make([]string, 0, len(event.Properties))
+		for key := range event.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			details = append(details, fmt.Sprintf("%s=%s", key, event.Properties[key]))
+		}
+	}
+
+	if len(details) == 0 {
+		return line
+	}
+
+	return fmt.Sprintf("%s: %s", line, strings.Join(details, ", "))
+}
+
+func firstNonEmpty(properties map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(properties[key]); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+func runEventHook(hook string, event casaos.Event, stdout, stderr io.Writer) error {
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	env := append(os.Environ(),
+		"CASAOS_EVENT_SOURCE_ID="+event.SourceID,
+		"CASAOS_EVENT_NAME="+event.Name,
+	)
+
+	for key, value := range event.Properties {
+		env = append(env, fmt.Sprintf("CASAOS_EVENT_%s=%s", strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToUpper(key), "_"), "_"), value))
+	}
+
+	hookCmd := exec.Command("sh", "-c", hook)
+	hookCmd.Env = env
+	hookCmd.Stdin = strings.NewReader(string(buf))
+	hookCmd.Stdout = stdout
+	hookCmd.Stderr = stderr
+
+	return hookCmd.Run()
+}
@@ -18,12 +18,9 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
 	"text/tabwriter"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/message_bus"
 	"github.com/spf13/cobra"
 )
 
@@ -31,33 +28,22 @@ import (
 var messageBusListActionTypesCmd = &cobra.Command{
 	Use:   "action-types",
 	Short: "list action types registered in message bus",
-	Run: func(cmd *cobra.Command, args []string) {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := casaosClient()
 		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathMessageBus)
-
-		client, err := message_bus.NewClientWithResponses(url)
-		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.GetActionTypesWithResponse(ctx)
+		types, err := client.Bus().ActionTypes(ctx)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			log.Fatalln("unexpected status code", response.Status())
-		}
-
-		if response.JSON200 == nil || len(*response.JSON200) == 0 {
-			return
+		if len(types) == 0 {
+			return nil
 		}
 
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
@@ -66,14 +52,16 @@ var messageBusListActionTypesCmd = &cobra.Command{
 		fmt.Fprintln(w, "SOURCE ID\tACTION NAME\tPROPERTY TYPES")
 		fmt.Fprintln(w, "---------\t----------\t--------------")
 
-		for _, actionType := range *response.JSON200 {
+		for _, actionType := range types {
 			propertyTypes := make([]string, 0)
-			for _, propertyType := range actionType.PropertyTypeList {
+			for _, propertyType := range actionType.PropertyTypes {
 				propertyTypes = append(propertyTypes, propertyType.Name)
 			}
 
 			fmt.Fprintf(w, "%s\t%s\t{%s}\n", actionType.SourceID, actionType.Name, strings.Join(propertyTypes, ", "))
 		}
+
+		return nil
 	},
 }
 
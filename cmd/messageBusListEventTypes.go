@@ -18,47 +18,32 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/message_bus"
 )
 
 // messageBusListEventTypesCmd represents the messageBusListEventTypes command
 var messageBusListEventTypesCmd = &cobra.Command{
 	Use:   "event-types",
 	Short: "list event types registered in message bus",
-	Run: func(cmd *cobra.Command, args []string) {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathMessageBus)
-
-		client, err := message_bus.NewClientWithResponses(url)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := casaosClient()
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.GetEventTypesWithResponse(ctx)
+		types, err := client.Bus().EventTypes(ctx)
 		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		if response.StatusCode() != http.StatusOK {
-			log.Fatalln("unexpected status code", response.Status())
+			return err
 		}
 
-		if response.JSON200 == nil || len(*response.JSON200) == 0 {
-			return
+		if len(types) == 0 {
+			return nil
 		}
 
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
@@ -67,14 +52,16 @@ var messageBusListEventTypesCmd = &cobra.Command{
 		fmt.Fprintln(w, "SOURCE ID\tEVENT NAME\tPROPERTY TYPES")
 		fmt.Fprintln(w, "---------\t----------\t--------------")
 
-		for _, eventType := range *response.JSON200 {
+		for _, eventType := range types {
 			propertyTypes := make([]string, 0)
-			for _, propertyType := range eventType.PropertyTypeList {
+			for _, propertyType := range eventType.PropertyTypes {
 				propertyTypes = append(propertyTypes, propertyType.Name)
 			}
 
 			fmt.Fprintf(w, "%s\t%s\t{%s}\n", eventType.SourceID, eventType.Name, strings.Join(propertyTypes, ", "))
 		}
+
+		return nil
 	},
 }
 
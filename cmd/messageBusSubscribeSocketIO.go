@@ -16,9 +16,11 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"reflect"
 	"strings"
 	"time"
@@ -35,13 +37,13 @@ import (
 var messageBusSubscribeSocketIOCmd = &cobra.Command{
 	Use:   "socketio",
 	Short: "subscribe to all entities in message bus via socketio",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
-		subscribeSIO(rootURL)
+		return subscribeSIO(cmd.OutOrStdout(), rootURL)
 	},
 }
 
@@ -59,18 +61,34 @@ func init() {
 	// messageBusSubscribeSocketIOCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func subscribeSIO(rootURL string) {
+func subscribeSIO(w io.Writer, rootURL string) error {
+	websocketTransport := websocket.Default
+	if sshTunnel != nil {
+		websocketTransport = &websocket.Transport{
+			NetDial: func(network, address string) (net.Conn, error) {
+				return sshTunnel.DialContext(context.Background(), network, address)
+			},
+		}
+	}
+
 	dialer := engineio.Dialer{
 		Transports: []transport.Transport{
-			websocket.Default,
+			websocketTransport,
 			polling.Default,
 		},
 	}
 
-	sioURL := fmt.Sprintf("http://%s/%s/socket.io", strings.TrimRight(rootURL, "/"), BasePathMessageBus)
-	conn, err := dialer.Dial(sioURL, nil)
-	if err != nil {
-		log.Fatalln(err.Error())
+	var conn engineio.Conn
+	var sioURL string
+
+	if err := dialWithFallback(rootURL, BasePathMessageBus, func(rootURL string) error {
+		sioURL = fmt.Sprintf("http://%s/%s/socket.io", strings.TrimRight(rootURL, "/"), BasePathMessageBus)
+
+		var err error
+		conn, err = dialer.Dial(sioURL, nil)
+		return err
+	}); err != nil {
+		return err
 	}
 	defer conn.Close()
 
@@ -87,9 +105,9 @@ func subscribeSIO(rootURL string) {
 				continue
 			}
 
-			log.Fatalln(err.Error())
+			return err
 		}
-		fmt.Printf("header: %+v, name: '%s'\n", header, name)
+		fmt.Fprintf(w, "header: %+v, name: '%s'\n", header, name)
 
 		values, err := decoder.DecodeArgs([]reflect.Type{
 			reflect.TypeOf(map[string]interface{}{}),
@@ -113,7 +131,7 @@ func subscribeSIO(rootURL string) {
 				log.Println(err.Error())
 			}
 
-			fmt.Println(string(output))
+			fmt.Fprintln(w, string(output))
 		}
 	}
 }
@@ -16,13 +16,14 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/message_bus"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/spf13/cobra"
-	"golang.org/x/net/websocket"
 )
 
 // messageBusSubscribeWebSocketCmd represents the messageBusSubscribeWebSocket command
@@ -42,41 +43,45 @@ func init() {
 	}
 }
 
-func subscribeWS(rootURL, messageType, sourceID, names string, bufferSize uint) {
-	var wsURL string
+func subscribeWS(w io.Writer, rootURL, messageType, sourceID, names string, bufferSize uint) error {
+	return subscribeWSEvents(rootURL, messageType, sourceID, names, bufferSize, func(event casaos.Event) error {
+		output, err := json.MarshalIndent(event, "", "  ")
+		if err != nil {
+			log.Println(err.Error())
+		}
 
-	if names == "" {
-		wsURL = fmt.Sprintf("ws://%s/%s/%s/%s", strings.TrimRight(rootURL, "/"), BasePathMessageBus, messageType, sourceID)
-	} else {
-		wsURL = fmt.Sprintf("ws://%s/%s/%s/%s?names=%s", strings.TrimRight(rootURL, "/"), BasePathMessageBus, messageType, sourceID, names)
-	}
+		fmt.Fprintln(w, string(output))
 
-	ws, err := websocket.Dial(wsURL, "", "http://localhost")
+		return nil
+	})
+}
+
+// subscribeWSEvents subscribes to messages of the source via websocket, and calls handler for each of them
+// until the connection is closed or the handler returns an error.
+func subscribeWSEvents(rootURL, messageType, sourceID, names string, bufferSize uint, handler func(event casaos.Event) error) error {
+	opts, err := casaosOptions()
 	if err != nil {
-		log.Fatalln(err.Error())
+		return err
 	}
-	defer ws.Close()
-
-	log.Printf("subscribed to %s via websocket", wsURL)
-
-	for {
-		msg := make([]byte, bufferSize)
-		n, err := ws.Read(msg)
-		if err != nil {
-			log.Fatalln(err.Error())
-		}
 
-		var event message_bus.Event
+	filter := casaos.Filter{
+		SourceID:    sourceID,
+		MessageType: messageType,
+		BufferSize:  bufferSize,
+	}
 
-		if err := json.Unmarshal(msg[:n], &event); err != nil {
-			log.Println(err.Error())
-		}
+	if names != "" {
+		filter.Names = strings.Split(names, ",")
+	}
 
-		output, err := json.MarshalIndent(event, "", "  ")
+	return dialWithFallback(rootURL, BasePathMessageBus, func(rootURL string) error {
+		client, err := casaos.NewClient(rootURL, opts...)
 		if err != nil {
-			log.Println(err.Error())
+			return err
 		}
 
-		fmt.Println(string(output))
-	}
+		log.Printf("subscribing to %s via websocket", client.Bus().URL(filter))
+
+		return client.Bus().SubscribeFunc(context.Background(), filter, handler)
+	})
 }
@@ -16,8 +16,6 @@ limitations under the License.
 package cmd
 
 import (
-	"log"
-
 	"github.com/spf13/cobra"
 )
 
@@ -25,28 +23,28 @@ import (
 var messageBusSubscribeWebSocketActionsCmd = &cobra.Command{
 	Use:   "actions",
 	Short: "subscribe to actions in message bus via websocket",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		sourceID, err := messageBusSubscribeWebSocketCmd.PersistentFlags().GetString(FlagMessageBusSourceID)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		bufferSize, err := messageBusSubscribeWebSocketCmd.PersistentFlags().GetUint(FlagMessageBusMessageBufferSize)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		actionNames, err := cmd.Flags().GetString(FlagMessageBusActionNames)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
-		subscribeWS(rootURL, "action", sourceID, actionNames, bufferSize)
+		return subscribeWS(cmd.OutOrStdout(), rootURL, "action", sourceID, actionNames, bufferSize)
 	},
 }
 
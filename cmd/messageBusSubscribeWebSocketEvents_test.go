@@ -0,0 +1,34 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessageBusSubscribeWebSocketEvents(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	// the mock message bus closes the connection after the last event, which ends the subscription
+	stdout, stderr, code := runCommand(t, server, "message-bus", "subscribe", "websocket", "--"+FlagMessageBusSourceID, "local-storage", "events")
+	assertExitCode(t, ExitCodeError, code, stderr)
+	assertGolden(t, "message_bus_subscribe_websocket_events", stdout)
+
+	if !strings.Contains(stderr, "ws://"+rootURLPlaceholder+"/v2/message_bus/event/local-storage") {
+		t.Errorf("expected the websocket url in stderr, got:\n%s", stderr)
+	}
+}
@@ -19,10 +19,8 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"strings"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/message_bus"
 	"github.com/spf13/cobra"
 )
 
@@ -30,32 +28,25 @@ import (
 var messageBusTriggerActionCmd = &cobra.Command{
 	Use:   "trigger",
 	Short: "trigger an action via message bus",
-	Run: func(cmd *cobra.Command, args []string) {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
-		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
+	RunE: func(cmd *cobra.Command, args []string) error {
 		sourceID, err := cmd.Flags().GetString(FlagMessageBusSourceID)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		actionName, err := cmd.Flags().GetString(FlagMessageBusActionName)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		properties, err := cmd.Flags().GetString(FlagMessageBusProperties)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathMessageBus)
-
-		client, err := message_bus.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		request := map[string]string{}
@@ -63,7 +54,7 @@ var messageBusTriggerActionCmd = &cobra.Command{
 		for _, property := range strings.Split(properties, ",") {
 			kv := strings.Split(property, "=")
 			if len(kv) != 2 {
-				log.Fatalln("invalid property:", property)
+				return fmt.Errorf("invalid property: %s", property)
 			}
 
 			request[kv[0]] = kv[1]
@@ -72,18 +63,7 @@ var messageBusTriggerActionCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 		defer cancel()
 
-		response, err := client.TriggerActionWithResponse(ctx, sourceID, actionName, request)
-		if err != nil {
-			log.Fatalln(err.Error())
-		}
-
-		if response == nil {
-			log.Fatalln("empty response")
-		}
-
-		if response.StatusCode() != http.StatusOK {
-			log.Fatalln("unexpected status code", response.Status())
-		}
+		return client.Bus().TriggerAction(ctx, sourceID, actionName, request)
 	},
 }
 
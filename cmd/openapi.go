@@ -0,0 +1,601 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FlagOpenAPIService = "service"
+	FlagOpenAPISpec    = "spec"
+
+	maxSchemaRefDepth = 16
+)
+
+// openAPISpecPaths are where each CasaOS service serves its OpenAPI spec, relative to the root url.
+var openAPISpecPaths = map[string]string{
+	"app_management": "doc/v2/app_management/openapi.yaml",
+	"casaos":         "doc/v2/casaos/openapi.yaml",
+	"local_storage":  "doc/v2/local_storage/openapi.yaml",
+	"message_bus":    "doc/v2/message_bus/openapi.yaml",
+	"user_service":   "doc/v2/users/openapi.yaml",
+}
+
+// embeddedOpenAPISpecs are specs of each service downloaded by `go generate` at build time, used when the server does
+// not publish them, e.g. an older CasaOS.
+//
+//go:embed openapi/*.yaml
+var embeddedOpenAPISpecs embed.FS
+
+var openAPIMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodHead,
+	http.MethodOptions,
+}
+
+// openAPISpec is the subset of an OpenAPI 3 spec needed to discover and call operations.
+type openAPISpec struct {
+	Servers    []openAPIServer            `yaml:"servers"`
+	Paths      map[string]openAPIPathItem `yaml:"paths"`
+	Components openAPIComponents          `yaml:"components"`
+}
+
+type openAPIServer struct {
+	URL string `yaml:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas       map[string]*openAPISchema     `yaml:"schemas"`
+	Parameters    map[string]openAPIParameter   `yaml:"parameters"`
+	RequestBodies map[string]openAPIRequestBody `yaml:"requestBodies"`
+	Responses     map[string]openAPIResponse    `yaml:"responses"`
+}
+
+type openAPIPathItem struct {
+	Parameters []openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation  `yaml:"get"`
+	Post       *openAPIOperation  `yaml:"post"`
+	Put        *openAPIOperation  `yaml:"put"`
+	Patch      *openAPIOperation  `yaml:"patch"`
+	Delete     *openAPIOperation  `yaml:"delete"`
+	Head       *openAPIOperation  `yaml:"head"`
+	Options    *openAPIOperation  `yaml:"options"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `yaml:"operationId"`
+	Summary     string                     `yaml:"summary"`
+	Description string                     `yaml:"description"`
+	Tags        []string                   `yaml:"tags"`
+	Parameters  []openAPIParameter         `yaml:"parameters"`
+	RequestBody *openAPIRequestBody        `yaml:"requestBody"`
+	Responses   map[string]openAPIResponse `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+	Ref         string         `yaml:"$ref"`
+	Name        string         `yaml:"name"`
+	In          string         `yaml:"in"`
+	Required    bool           `yaml:"required"`
+	Description string         `yaml:"description"`
+	Schema      *openAPISchema `yaml:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Ref         string                      `yaml:"$ref"`
+	Required    bool                        `yaml:"required"`
+	Description string                      `yaml:"description"`
+	Content     map[string]openAPIMediaType `yaml:"content"`
+}
+
+type openAPIResponse struct {
+	Ref         string                      `yaml:"$ref"`
+	Description string                      `yaml:"description"`
+	Content     map[string]openAPIMediaType `yaml:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+type openAPISchema struct {
+	Ref         string                    `yaml:"$ref"`
+	Type        string                    `yaml:"type"`
+	Format      string                    `yaml:"format"`
+	Description string                    `yaml:"description"`
+	Enum        []interface{}             `yaml:"enum"`
+	Items       *openAPISchema            `yaml:"items"`
+	Properties  map[string]*openAPISchema `yaml:"properties"`
+	Required    []string                  `yaml:"required"`
+	AllOf       []*openAPISchema          `yaml:"allOf"`
+
+	AdditionalProperties *openAPIAdditionalProperties `yaml:"additionalProperties"`
+}
+
+// openAPIAdditionalProperties is `additionalProperties` of an object schema, which is either a boolean, or the schema
+// of properties not listed in `properties`. When it is not given, any additional property is allowed.
+type openAPIAdditionalProperties struct {
+	Allowed bool
+	Schema  *openAPISchema
+}
+
+func (a *openAPIAdditionalProperties) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&a.Allowed); err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+	return unmarshal(&a.Schema)
+}
+
+// apiOperation is an operation found in the spec of a service, with references already resolved.
+type apiOperation struct {
+	Service     string
+	Method      string
+	Path        string
+	BasePath    string
+	Operation   *openAPIOperation
+	Parameters  []openAPIParameter
+	RequestBody *openAPIRequestBody
+
+	spec *openAPISpec
+}
+
+func addOpenAPIFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(FlagOpenAPIService, "s", "", fmt.Sprintf("only load the spec of this service (one of %s)", strings.Join(openAPIServiceNames(), ", ")))
+	cmd.Flags().StringArray(FlagOpenAPISpec, []string{}, "load the spec of a service from a local file or url instead, in service=location format")
+}
+
+func openAPIServiceNames() []string {
+	names := lo.Keys(openAPISpecPaths)
+	sort.Strings(names)
+	return names
+}
+
+// loadAPIOperations loads the OpenAPI spec of each service selected by flags, and returns all operations found.
+// A service whose spec cannot be loaded is reported and skipped, unless it is the only service selected.
+func loadAPIOperations(cmd *cobra.Command) ([]apiOperation, error) {
+	service, err := cmd.Flags().GetString(FlagOpenAPIService)
+	if err != nil {
+		return nil, err
+	}
+
+	specFlags, err := cmd.Flags().GetStringArray(FlagOpenAPISpec)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := map[string]string{}
+	for _, s := range specFlags {
+		name, location, found := strings.Cut(s, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid spec %s - should be in service=location format", s)
+		}
+
+		if _, ok := openAPISpecPaths[name]; !ok {
+			return nil, fmt.Errorf("unknown service %s - should be one of %s", name, strings.Join(openAPIServiceNames(), ", "))
+		}
+
+		locations[name] = location
+	}
+
+	services := openAPIServiceNames()
+	if service != "" {
+		if _, ok := openAPISpecPaths[service]; !ok {
+			return nil, fmt.Errorf("unknown service %s - should be one of %s", service, strings.Join(openAPIServiceNames(), ", "))
+		}
+		services = []string{service}
+	}
+
+	operations := []apiOperation{}
+
+	for _, name := range services {
+		spec, err := loadOpenAPISpec(name, locations[name])
+		if err != nil {
+			if len(services) == 1 {
+				return nil, err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "skipping %s: %s\n", name, err.Error())
+			continue
+		}
+
+		operations = append(operations, spec.operations(name)...)
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Service != operations[j].Service {
+			return operations[i].Service < operations[j].Service
+		}
+		return operations[i].Operation.OperationID < operations[j].Operation.OperationID
+	})
+
+	return operations, nil
+}
+
+// findAPIOperation looks up an operation by its id, which can be prefixed with a service name as `service.operationId`.
+func findAPIOperation(operations []apiOperation, operationID string) (*apiOperation, error) {
+	service, id, found := strings.Cut(operationID, ".")
+	if !found {
+		service, id = "", operationID
+	}
+
+	matches := lo.Filter(operations, func(operation apiOperation, _ int) bool {
+		return operation.Operation.OperationID == id && (service == "" || operation.Service == service)
+	})
+
+	switch len(matches) {
+	case 0:
+		return nil, notFoundError{err: fmt.Errorf("operation %s not found - use `casaos-cli api list` to see available operations", operationID)}
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("operation %s is found in more than one service - use `service.%s` or --%s to select one", operationID, id, FlagOpenAPIService)
+	}
+}
+
+// loadOpenAPISpec loads the spec from the given file or url, or from the service via gateway when location is empty,
+// falling back to the spec embedded at build time.
+func loadOpenAPISpec(service, location string) (*openAPISpec, error) {
+	var buf []byte
+
+	switch {
+	case location == "":
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		response, body, err := apiRequest(ctx, http.MethodGet, openAPISpecPaths[service], http.Header{}, nil)
+		if err == nil && response.StatusCode != http.StatusOK {
+			err = fmt.Errorf("%s - failed to get OpenAPI spec from /%s - is the %s service running, or use --%s to load it from elsewhere", response.Status, openAPISpecPaths[service], service, FlagOpenAPISpec)
+		}
+
+		if err != nil {
+			embedded, embeddedErr := embeddedOpenAPISpecs.ReadFile("openapi/" + service + ".yaml")
+			if embeddedErr != nil {
+				return nil, err
+			}
+
+			body = embedded
+		}
+
+		buf = body
+
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s - failed to get OpenAPI spec from %s", response.Status, location)
+		}
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		buf = body
+
+	default:
+		body, err := os.ReadFile(location)
+		if err != nil {
+			return nil, err
+		}
+
+		buf = body
+	}
+
+	// JSON is valid YAML, so both formats of spec are supported
+	var spec openAPISpec
+	if err := yaml.Unmarshal(buf, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec of %s: %w", service, err)
+	}
+
+	return &spec, nil
+}
+
+func (s *openAPISpec) basePath() string {
+	if len(s.Servers) == 0 {
+		return ""
+	}
+
+	u, err := url.Parse(s.Servers[0].URL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimRight(u.Path, "/")
+}
+
+func (s *openAPISpec) operations(service string) []apiOperation {
+	operations := []apiOperation{}
+
+	for path, item := range s.Paths {
+		for _, method := range openAPIMethods {
+			operation := item.operation(method)
+			if operation == nil {
+				continue
+			}
+
+			// operation level parameters override path level ones with the same name and location
+			parameters := map[string]openAPIParameter{}
+			order := []string{}
+			for _, p := range append(append([]openAPIParameter{}, item.Parameters...), operation.Parameters...) {
+				p = s.resolveParameter(p)
+				key := p.In + "/" + p.Name
+				if _, ok := parameters[key]; !ok {
+					order = append(order, key)
+				}
+				parameters[key] = p
+			}
+
+			operations = append(operations, apiOperation{
+				Service:     service,
+				Method:      method,
+				Path:        path,
+				BasePath:    s.basePath(),
+				Operation:   operation,
+				Parameters:  lo.Map(order, func(key string, _ int) openAPIParameter { return parameters[key] }),
+				RequestBody: s.resolveRequestBody(operation.RequestBody),
+				spec:        s,
+			})
+		}
+	}
+
+	return operations
+}
+
+func (item openAPIPathItem) operation(method string) *openAPIOperation {
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPost:
+		return item.Post
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPatch:
+		return item.Patch
+	case http.MethodDelete:
+		return item.Delete
+	case http.MethodHead:
+		return item.Head
+	case http.MethodOptions:
+		return item.Options
+	default:
+		return nil
+	}
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func (s *openAPISpec) resolveParameter(p openAPIParameter) openAPIParameter {
+	for i := 0; p.Ref != "" && i < maxSchemaRefDepth; i++ {
+		p = s.Components.Parameters[refName(p.Ref)]
+	}
+	return p
+}
+
+func (s *openAPISpec) resolveRequestBody(b *openAPIRequestBody) *openAPIRequestBody {
+	for i := 0; b != nil && b.Ref != "" && i < maxSchemaRefDepth; i++ {
+		resolved, ok := s.Components.RequestBodies[refName(b.Ref)]
+		if !ok {
+			return nil
+		}
+		b = &resolved
+	}
+	return b
+}
+
+func (s *openAPISpec) resolveResponse(r openAPIResponse) openAPIResponse {
+	for i := 0; r.Ref != "" && i < maxSchemaRefDepth; i++ {
+		r = s.Components.Responses[refName(r.Ref)]
+	}
+	return r
+}
+
+// resolveSchema follows references, and merges `allOf` subschemas into one, so that properties can be listed directly.
+func (s *openAPISpec) resolveSchema(schema *openAPISchema) *openAPISchema {
+	return s.resolveSchemaWithDepth(schema, 0)
+}
+
+func (s *openAPISpec) resolveSchemaWithDepth(schema *openAPISchema, depth int) *openAPISchema {
+	if schema == nil || depth > maxSchemaRefDepth {
+		return schema
+	}
+
+	if schema.Ref != "" {
+		return s.resolveSchemaWithDepth(s.Components.Schemas[refName(schema.Ref)], depth+1)
+	}
+
+	if len(schema.AllOf) == 0 {
+		return schema
+	}
+
+	merged := *schema
+	merged.AllOf = nil
+	merged.Properties = map[string]*openAPISchema{}
+	for name, property := range schema.Properties {
+		merged.Properties[name] = property
+	}
+
+	for _, sub := range schema.AllOf {
+		sub = s.resolveSchemaWithDepth(sub, depth+1)
+		if sub == nil {
+			continue
+		}
+
+		if merged.Type == "" {
+			merged.Type = sub.Type
+		}
+
+		if merged.AdditionalProperties == nil {
+			merged.AdditionalProperties = sub.AdditionalProperties
+		}
+
+		for name, property := range sub.Properties {
+			merged.Properties[name] = property
+		}
+
+		merged.Required = append(merged.Required, sub.Required...)
+	}
+
+	return &merged
+}
+
+// schemaType describes a schema in short form, e.g. `string`, `integer(int64)`, `[]ComposeApp`.
+func schemaType(schema *openAPISchema) string {
+	switch {
+	case schema == nil:
+		return ""
+	case schema.Ref != "":
+		return refName(schema.Ref)
+	case schema.Type == "array":
+		return "[]" + schemaType(schema.Items)
+	case len(schema.AllOf) > 0:
+		return strings.Join(lo.Map(schema.AllOf, func(sub *openAPISchema, _ int) string { return schemaType(sub) }), "&")
+	case schema.Format != "":
+		return fmt.Sprintf("%s(%s)", schema.Type, schema.Format)
+	case schema.Type == "":
+		return "any"
+	default:
+		return schema.Type
+	}
+}
+
+// validateSchemaValue checks a value given as a string, e.g. from command line, against a primitive schema.
+func validateSchemaValue(schema *openAPISchema, value string) error {
+	if schema == nil {
+		return nil
+	}
+
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("%s is not an integer", value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s is not a number", value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s is not a boolean", value)
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		values := lo.Map(schema.Enum, func(v interface{}, _ int) string { return fmt.Sprint(v) })
+		if !lo.Contains(values, value) {
+			return fmt.Errorf("%s is not one of %s", value, strings.Join(values, ", "))
+		}
+	}
+
+	return nil
+}
+
+// validateSchemaObject checks top level properties of a decoded JSON object against an object schema.
+func (s *openAPISpec) validateSchemaObject(schema *openAPISchema, object map[string]interface{}) error {
+	schema = s.resolveSchema(schema)
+	if schema == nil || (len(schema.Properties) == 0 && schema.AdditionalProperties == nil) {
+		return nil
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("required property %s is missing", name)
+		}
+	}
+
+	for name, value := range object {
+		property, ok := schema.Properties[name]
+		if !ok {
+			switch {
+			case schema.AdditionalProperties == nil:
+				continue
+			case !schema.AdditionalProperties.Allowed:
+				names := lo.Keys(schema.Properties)
+				sort.Strings(names)
+
+				return fmt.Errorf("unknown property %s - should be one of %s", name, strings.Join(names, ", "))
+			default:
+				property = schema.AdditionalProperties.Schema
+			}
+		}
+
+		property = s.resolveSchema(property)
+		if property == nil || value == nil {
+			continue
+		}
+
+		var valid bool
+		switch value.(type) {
+		case string:
+			valid = property.Type == "" || property.Type == "string"
+		case bool:
+			valid = property.Type == "" || property.Type == "boolean"
+		case float64, int64:
+			valid = property.Type == "" || property.Type == "number" || property.Type == "integer"
+		case []interface{}:
+			valid = property.Type == "" || property.Type == "array"
+		case map[string]interface{}:
+			valid = property.Type == "" || property.Type == "object"
+		default:
+			valid = true
+		}
+
+		if !valid {
+			return fmt.Errorf("property %s should be of type %s", name, schemaType(property))
+		}
+
+		if str, ok := value.(string); ok {
+			if err := validateSchemaValue(property, str); err != nil {
+				return fmt.Errorf("property %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
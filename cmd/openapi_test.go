@@ -0,0 +1,72 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const testOpenAPISpec = `
+components:
+  schemas:
+    Strict:
+      type: object
+      additionalProperties: false
+      properties:
+        name: {type: string}
+        port: {type: integer}
+        enabled: {type: boolean}
+    Labels:
+      type: object
+      properties:
+        name: {type: string}
+      additionalProperties:
+        type: string
+    Open:
+      type: object
+      properties:
+        name: {type: string}
+`
+
+func TestValidateSchemaObject(t *testing.T) {
+	var spec openAPISpec
+	if err := yaml.Unmarshal([]byte(testOpenAPISpec), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		schema string
+		object map[string]interface{}
+		err    string
+	}{
+		{"Strict", map[string]interface{}{"name": "jellyfin", "port": float64(8096)}, ""},
+		{"Strict", map[string]interface{}{"nmae": "jellyfin"}, "unknown property nmae - should be one of enabled, name, port"},
+		{"Labels", map[string]interface{}{"name": "jellyfin", "com.example.tier": "media"}, ""},
+		{"Labels", map[string]interface{}{"com.example.replicas": float64(2)}, "property com.example.replicas should be of type string"},
+		{"Open", map[string]interface{}{"name": "jellyfin", "anything": true}, ""},
+	} {
+		err := spec.validateSchemaObject(&openAPISchema{Ref: "#/components/schemas/" + c.schema}, c.object)
+
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s: expected %v to be valid, got %s", c.schema, c.object, err.Error())
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("%s: expected error %q for %v, got %v", c.schema, c.err, c.object, err)
+		}
+	}
+}
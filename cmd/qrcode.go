@@ -17,7 +17,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/skip2/go-qrcode"
 	"github.com/spf13/cobra"
@@ -30,7 +29,7 @@ var qrcodeCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
 		if err != nil {
-			log.Fatalln(err.Error())
+			return err
 		}
 
 		qrCode, err := qrcode.New(rootURL, qrcode.Medium)
@@ -38,7 +37,7 @@ var qrcodeCmd = &cobra.Command{
 			return err
 		}
 
-		fmt.Println(qrCode.ToSmallString(false))
+		fmt.Fprintln(cmd.OutOrStdout(), qrCode.ToSmallString(false))
 
 		return nil
 	},
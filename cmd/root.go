@@ -16,11 +16,18 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/IceWhaleTech/CasaOS-Common/utils/constants"
 	"github.com/go-ini/ini"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/cobra"
@@ -34,9 +41,14 @@ const (
 	FlagFile    = "file"
 	FlagForce   = "force"
 	FlagRootURL = "root-url"
+	FlagToken   = "token"
+
+	EnvToken = "CASAOS_TOKEN"
 
 	GatewayPath = "/etc/casaos/gateway.ini"
 
+	DefaultRootURL = "localhost:80"
+
 	DefaultTimeout = 10 * time.Second
 	RootGroupID    = "casaos-cli"
 )
@@ -53,55 +65,259 @@ var (
 var rootCmd = &cobra.Command{
 	Use:   "casaos-cli",
 	Short: "A command line interface for CasaOS",
+	Long:  "A command line interface for CasaOS\n\n" + exitCodeHelp,
+
+	// errors and usage are printed by Execute, in the format given by --output
+	SilenceErrors: true,
+	SilenceUsage:  true,
+
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutput(cmd); err != nil {
+			return err
+		}
+
+		if err := setupContext(cmd); err != nil {
+			return err
+		}
+
+		if err := setupSSH(cmd); err != nil {
+			return err
+		}
+
+		if err := setupHTTPTrace(cmd); err != nil {
+			return err
+		}
+
+		return setupDirect(cmd)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	wrapUsageErrors(rootCmd)
+
+	cmd, err := rootCmd.ExecuteC()
+
+	if err := writeHTTPTrace(); err != nil {
+		log.Printf("failed to write trace file: %s", err.Error())
+	}
+
 	if err != nil {
-		os.Exit(1)
+		os.Exit(printError(os.Stderr, cmd, err))
 	}
 }
 
 func init() {
-	url := ""
-
-	rootCmd.PersistentFlags().StringP(FlagRootURL, "u", "", "root url of CasaOS API")
-
-	if rootCmd.PersistentFlags().Changed(FlagRootURL) {
-		url = rootCmd.PersistentFlags().Lookup(FlagRootURL).Value.String()
-	} else {
-		if _, err := os.Stat(GatewayPath); err == nil {
-			cfgs, err := ini.Load(GatewayPath)
-			if err != nil {
-				log.Println("No gateway config found, use default root url")
-			}
-
-			port := cfgs.Section("gateway").Key("port").Value()
-			if port != "" {
-				url = fmt.Sprintf("localhost:%s", port)
-			}
-		}
+	url := DefaultRootURL
+	if buf, err := os.ReadFile(GatewayPath); err == nil {
+		url = gatewayRootURL(buf)
 	}
 
-	if url == "" {
-		url = "localhost:80"
-	}
+	rootCmd.PersistentFlags().StringP(FlagRootURL, "u", url, "root url of CasaOS API")
+	rootCmd.PersistentFlags().String(FlagToken, "", fmt.Sprintf("access token for commands that require authentication (default to $%s)", EnvToken))
+	rootCmd.PersistentFlags().String(FlagSSH, "", fmt.Sprintf("manage a remote CasaOS through an SSH tunnel to user@host[:port], using keys from ssh agent or ~/.ssh - root url is then resolved on the remote host (default to $%s, or the one in context)", EnvSSH))
+	rootCmd.PersistentFlags().String(FlagContext, "", fmt.Sprintf("context to use for root url and --%s, see 'context --help' (default to $%s, or the current context)", FlagSSH, EnvContext))
+	rootCmd.PersistentFlags().Bool(FlagDirect, false, fmt.Sprintf("send requests to each service directly instead of via gateway, using addresses in %s/*.url (automatic when gateway refuses connection)", constants.DefaultRuntimePath))
+	rootCmd.PersistentFlags().CountP(FlagVerbose, "v", "log HTTP requests to stderr - repeat for more details, i.e. -vv for headers and -vvv for bodies")
+	rootCmd.PersistentFlags().Bool(FlagDebugHTTP, false, "log HTTP requests with headers and bodies to stderr (same as -vvv)")
+	rootCmd.PersistentFlags().String(FlagOutput, OutputText, fmt.Sprintf("output format of errors on stderr - %s or %s", OutputText, OutputJSON))
+	rootCmd.PersistentFlags().String(FlagTraceFile, "", "write HTTP requests and responses to a HAR file, e.g. trace.har, for bug reports - passwords and tokens are redacted")
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err: err}
+	})
 
-	rootCmd.PersistentFlags().Set(FlagRootURL, url)
 	rootCmd.AddGroup(&cobra.Group{
 		ID:    RootGroupID,
 		Title: "Services",
 	})
 }
 
+// gatewayRootURL returns the root url of CasaOS API on the host, from the content of its gateway config file.
+func gatewayRootURL(config []byte) string {
+	cfgs, err := ini.Load(config)
+	if err != nil {
+		log.Println("Invalid gateway config found, use default root url")
+		return DefaultRootURL
+	}
+
+	port := cfgs.Section("gateway").Key("port").Value()
+	if port == "" {
+		return DefaultRootURL
+	}
+
+	return fmt.Sprintf("localhost:%s", port)
+}
+
 func trim(s string, l uint) string {
 	if len(s) > int(l) {
 		return s[:l] + "..."
 	}
 	return s
 }
+
+// accessToken returns the access token from `--token` flag, or from environment variable if the flag is not set.
+func accessToken() (string, error) {
+	token, err := rootCmd.PersistentFlags().GetString(FlagToken)
+	if err != nil {
+		return "", err
+	}
+
+	if token == "" {
+		token = os.Getenv(EnvToken)
+	}
+
+	return token, nil
+}
+
+// casaosClient returns a client of the `pkg/casaos` package, with root url and access token from flags.
+func casaosClient() (*casaos.Client, error) {
+	rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := casaosOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return casaos.NewClient(rootURL, opts...)
+}
+
+// casaosOptions returns options of the `pkg/casaos` client for access token, SSH tunnel and web UI hostname
+// from flags.
+func casaosOptions() ([]casaos.Option, error) {
+	token, err := accessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []casaos.Option{casaos.WithToken(token)}
+
+	if sshTunnel != nil {
+		// the root url is on the remote host, so its apps are reached at the SSH host
+		return append(opts, casaos.WithDialContext(sshTunnel.DialContext), casaos.WithHostname(sshTunnel.hostname())), nil
+	}
+
+	rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// apps of CasaOS on this host are opened from other devices, so its LAN address is more useful than loopback
+	if isLoopback(rootURL) {
+		if hostname, err := hostname(); err == nil {
+			opts = append(opts, casaos.WithHostname(hostname))
+		}
+	}
+
+	return opts, nil
+}
+
+// isLoopback returns true if the host of root url is `localhost` or a loopback address.
+func isLoopback(rootURL string) bool {
+	host := strings.TrimRight(strings.TrimPrefix(rootURL, "http://"), "/")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// hostname returns the first non-loopback IPv4 address of this host, which is how CasaOS is reached on LAN.
+func hostname() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	for _, i := range ifaces {
+		addrs, err := i.Addrs()
+		if err != nil {
+			return "", err
+		}
+
+		for _, addr := range addrs {
+			var ip net.IP
+			switch v := addr.(type) {
+			case *net.IPNet:
+				ip = v.IP
+			case *net.IPAddr:
+				ip = v.IP
+			}
+
+			if ip != nil && !ip.IsLoopback() && ip.To4() != nil {
+				return ip.String(), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("could not find hostname")
+}
+
+// v1Request sends a request to a v1 API, which is not covered by `pkg/casaos`. An access token is
+// attached when available. The response body is returned only if the request succeeded.
+func v1Request(ctx context.Context, method, path, contentType string, body io.Reader, service string) ([]byte, error) {
+	rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("http://%s/%s", rootURL, strings.TrimLeft(path, "/"))
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	if token, err := accessToken(); err == nil && token != "" {
+		request.Header.Set("Authorization", token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// v1 API may return HTTP 200 with an error code in `success` field
+	success := json.Get(buf, "success")
+	if response.StatusCode != http.StatusOK || (success.LastError() == nil && success.ToInt() != http.StatusOK) {
+		message := json.Get(buf, "message").ToString()
+		if message == "" {
+			message = string(buf)
+		}
+
+		if message == "" {
+			message = fmt.Sprintf("is the %s service running?", service)
+		}
+
+		if response.StatusCode == http.StatusUnauthorized {
+			message += fmt.Sprintf(" - use `casaos-cli user login` to get an access token, then set it via --%s or $%s", FlagToken, EnvToken)
+		}
+
+		return nil, &casaos.StatusError{StatusCode: response.StatusCode, Status: response.Status, Message: message}
+	}
+
+	return buf, nil
+}
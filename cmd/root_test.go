@@ -0,0 +1,276 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/net/websocket"
+)
+
+// rootURLPlaceholder replaces the address of the mock server in outputs, so they can be compared with golden files.
+const rootURLPlaceholder = "casaos.local"
+
+var (
+	update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+	// defaultTransport is the HTTP transport before any command wraps it, e.g. with --direct or --trace-file.
+	defaultTransport = http.DefaultTransport
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	wrapUsageErrors(rootCmd)
+
+	os.Exit(m.Run())
+}
+
+// newMockCasaOS starts a server serving recorded responses in testdata/fixtures, laid out by URL path, e.g.
+// `GET /v2/local_storage/merge` is served from `testdata/fixtures/v2/local_storage/merge.json`. It serves the gateway,
+// its management API and the services behind it at the same address.
+//
+// A `.jsonl` fixture is served as a websocket instead, sending each line as a message and then closing the connection,
+// as the message bus does for events and actions.
+func newMockCasaOS(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture := filepath.Join("testdata", "fixtures", filepath.FromSlash(strings.Trim(r.URL.Path, "/")))
+
+		if messages, err := os.ReadFile(fixture + ".jsonl"); err == nil {
+			websocket.Handler(func(ws *websocket.Conn) {
+				scanner := bufio.NewScanner(bytes.NewReader(messages))
+				for scanner.Scan() {
+					if _, err := ws.Write(scanner.Bytes()); err != nil {
+						return
+					}
+				}
+			}).ServeHTTP(w, r)
+			return
+		}
+
+		buf, err := os.ReadFile(fixture + ".json")
+		if err != nil || r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", MINEApplicationJSON)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"not found"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", MINEApplicationJSON)
+		w.Write(buf)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// runCommand runs the command line against the mock server, and returns stdout, stderr and the exit code, with the
+// address of the server replaced by rootURLPlaceholder.
+//
+// rootCmd and the HTTP transport are global, so flags and everything set up by PersistentPreRunE are reset before each
+// run, and tests using it must not run in parallel.
+func runCommand(t *testing.T, server *httptest.Server, args ...string) (string, string, int) {
+	t.Helper()
+
+	resetCommand(t)
+	t.Cleanup(func() { resetCommand(t) })
+
+	// keep contexts, caches and environment of the host out of tests
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv(EnvSSH, "")
+	t.Setenv(EnvContext, "")
+	t.Setenv(EnvToken, "")
+
+	address := server.Listener.Addr().String()
+
+	var stdout, stderr bytes.Buffer
+
+	log.SetOutput(&stderr)
+	defer log.SetOutput(os.Stderr)
+
+	rootCmd.SetArgs(append([]string{"--" + FlagRootURL, address}, args...))
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+
+	code := ExitCodeOK
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		code = printError(&stderr, cmd, err)
+	}
+
+	return strings.ReplaceAll(stdout.String(), address, rootURLPlaceholder),
+		strings.ReplaceAll(stderr.String(), address, rootURLPlaceholder),
+		code
+}
+
+// resetCommand resets flags of every command to their defaults, and undoes what PersistentPreRunE set up.
+func resetCommand(t *testing.T) {
+	t.Helper()
+
+	var reset func(cmd *cobra.Command)
+	reset = func(cmd *cobra.Command) {
+		for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+			flags.VisitAll(func(f *pflag.Flag) {
+				var err error
+				if value, ok := f.Value.(pflag.SliceValue); ok {
+					err = value.Replace(sliceDefault(f.DefValue))
+				} else {
+					err = f.Value.Set(f.DefValue)
+				}
+
+				if err != nil {
+					t.Fatalf("failed to reset --%s - %s", f.Name, err.Error())
+				}
+
+				f.Changed = false
+			})
+		}
+
+		for _, c := range cmd.Commands() {
+			reset(c)
+		}
+	}
+
+	reset(rootCmd)
+
+	http.DefaultTransport = defaultTransport
+	sshTunnel = nil
+	httpTrace = nil
+}
+
+// sliceDefault parses the default of a slice flag, e.g. `[a,b]`.
+func sliceDefault(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if value == "" {
+		return []string{}
+	}
+
+	return strings.Split(value, ",")
+}
+
+// assertGolden compares output with the golden file testdata/golden/<name>.golden, or updates it with -update.
+func assertGolden(t *testing.T, name, output string) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", "golden", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(golden, []byte(output), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%s - run `go test ./cmd -update` to create it", err.Error())
+	}
+
+	if output != string(expected) {
+		t.Errorf("output does not match %s\n--- expected\n%s\n--- actual\n%s", golden, expected, output)
+	}
+}
+
+func assertExitCode(t *testing.T, expected, actual int, stderr string) {
+	t.Helper()
+
+	if actual != expected {
+		t.Fatalf("expected exit code %d, got %d - stderr:\n%s", expected, actual, stderr)
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "app-management", "show", "local", "unknown")
+	assertExitCode(t, ExitCodeNotFound, code, stderr)
+	assertGolden(t, "not_found_error_text", stdout+stderr)
+}
+
+func TestNotFoundErrorJSON(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "--output", OutputJSON, "app-management", "show", "local", "unknown")
+	assertExitCode(t, ExitCodeNotFound, code, stderr)
+	assertGolden(t, "not_found_error_json", stdout+stderr)
+}
+
+func TestUsageError(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	_, stderr, code := runCommand(t, server, "--output", OutputJSON, "app-management", "show", "local")
+	assertExitCode(t, ExitCodeUsage, code, stderr)
+	assertGolden(t, "usage_error_json", stderr)
+}
+
+func TestResetCommand(t *testing.T) {
+	server := newMockCasaOS(t)
+
+	// flags of a previous run must not leak into the next one
+	if _, stderr, code := runCommand(t, server, "--output", OutputJSON, "-vv", "local-storage", "list", "merges"); code != ExitCodeOK {
+		t.Fatalf("unexpected exit code %d - stderr:\n%s", code, stderr)
+	}
+
+	if outputFormat() != OutputJSON {
+		t.Fatalf("expected --output to be %s during the run", OutputJSON)
+	}
+
+	resetCommand(t)
+
+	if outputFormat() != OutputText {
+		t.Errorf("expected --output to be reset to %s, got %s", OutputText, outputFormat())
+	}
+
+	if verbose, _ := rootCmd.PersistentFlags().GetCount(FlagVerbose); verbose != 0 || rootCmd.PersistentFlags().Changed(FlagVerbose) {
+		t.Errorf("expected --%s to be reset, got %d", FlagVerbose, verbose)
+	}
+
+	if httpTrace != nil || http.DefaultTransport != defaultTransport {
+		t.Errorf("expected HTTP transport to be reset, got %T", http.DefaultTransport)
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for rootURL, expected := range map[string]bool{
+		"localhost:80":        true,
+		"http://127.0.0.1:80": true,
+		"[::1]:80":            true,
+		"localhost":           true,
+		"192.168.1.10:80":     false,
+		"casaos.local":        false,
+	} {
+		if actual := isLoopback(rootURL); actual != expected {
+			t.Errorf("expected isLoopback(%q) to be %v, got %v", rootURL, expected, actual)
+		}
+	}
+}
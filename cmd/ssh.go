@@ -0,0 +1,278 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	FlagSSH = "ssh"
+
+	EnvSSH = "CASAOS_SSH"
+
+	DefaultSSHPort = "22"
+)
+
+// default private keys tried after the ssh agent, in the same order as OpenSSH
+var sshIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sshTunnel is set when `--ssh` is given, so that all connections to CasaOS are dialed through it.
+var sshTunnel *sshDialer
+
+// sshDialer dials connections from the remote host over a single SSH connection, which is opened on first use.
+type sshDialer struct {
+	user    string
+	address string
+
+	once   sync.Once
+	client *ssh.Client
+	err    error
+}
+
+// setupSSH replaces the default HTTP transport with one that dials through SSH, when `--ssh` is given.
+func setupSSH(cmd *cobra.Command) error {
+	target, err := cmd.Flags().GetString(FlagSSH)
+	if err != nil {
+		return err
+	}
+
+	if target == "" {
+		target = os.Getenv(EnvSSH)
+	}
+
+	if target == "" {
+		return nil
+	}
+
+	sshUser, address, err := parseSSHTarget(target)
+	if err != nil {
+		return usageError{err: err}
+	}
+
+	sshTunnel = &sshDialer{user: sshUser, address: address}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected default HTTP transport %T", http.DefaultTransport)
+	}
+
+	transport = transport.Clone()
+	transport.Proxy = nil
+	transport.DialContext = sshTunnel.DialContext
+
+	http.DefaultTransport = transport
+
+	// the default root url comes from gateway config on this host, so it is resolved on the remote host instead
+	if !cmd.Flags().Changed(FlagRootURL) {
+		rootURL := DefaultRootURL
+		if buf, err := sshTunnel.readFile(GatewayPath); err == nil {
+			rootURL = gatewayRootURL(buf)
+		}
+
+		if err := cmd.Flags().Set(FlagRootURL, rootURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refuseOverSSH returns an error when `--ssh` is given, for what acts on this host rather than through CasaOS API,
+// e.g. systemd or files of CasaOS.
+func refuseOverSSH(what string) error {
+	if sshTunnel == nil {
+		return nil
+	}
+
+	return usageError{err: fmt.Errorf("%s only works on the CasaOS host itself, and cannot be used with --%s - run it on %s instead", what, FlagSSH, sshTunnel.address)}
+}
+
+// parseSSHTarget parses `[user@]host[:port]` into user and address, with the current user and port 22 by default.
+func parseSSHTarget(target string) (string, string, error) {
+	sshUser, hostPort := "", target
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		sshUser, hostPort = target[:i], target[i+1:]
+	}
+
+	if sshUser == "" {
+		current, err := user.Current()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get current user for --%s - %w", FlagSSH, err)
+		}
+		sshUser = current.Username
+	}
+
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		hostPort = net.JoinHostPort(strings.Trim(hostPort, "[]"), DefaultSSHPort)
+	}
+
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil || host == "" {
+		return "", "", fmt.Errorf("invalid --%s %s - expected user@host[:port]", FlagSSH, target)
+	}
+
+	return sshUser, hostPort, nil
+}
+
+// hostname returns the host of the SSH address, which is the remote host of CasaOS.
+func (d *sshDialer) hostname() string {
+	host, _, err := net.SplitHostPort(d.address)
+	if err != nil {
+		return d.address
+	}
+
+	return host
+}
+
+// DialContext dials the address from the remote host, e.g. `localhost:80` is the gateway on that host.
+func (d *sshDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Dial(network, address)
+}
+
+// readFile reads a file on the remote host.
+func (d *sshDialer) readFile(path string) ([]byte, error) {
+	client, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	buf, err := session.Output("cat '" + strings.ReplaceAll(path, "'", `'\''`) + "'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s on %s - %s", path, d.address, strings.TrimSpace(err.Error()+" "+stderr.String()))
+	}
+
+	return buf, nil
+}
+
+func (d *sshDialer) connect() (*ssh.Client, error) {
+	d.once.Do(func() {
+		hostKeyCallback, err := sshHostKeyCallback()
+		if err != nil {
+			d.err = err
+			return
+		}
+
+		config := &ssh.ClientConfig{
+			User:            d.user,
+			Auth:            sshAuthMethods(),
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         DefaultTimeout,
+		}
+
+		d.client, d.err = ssh.Dial("tcp", d.address, config)
+	})
+
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to connect to %s@%s via ssh - %w", d.user, d.address, d.err)
+	}
+
+	return d.client, nil
+}
+
+// sshAuthMethods authenticates with keys from the ssh agent if running, then with unencrypted default private keys.
+func sshAuthMethods() []ssh.AuthMethod {
+	methods := []ssh.AuthMethod{}
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return methods
+	}
+
+	signers := []ssh.Signer{}
+
+	for _, name := range sshIdentityFiles {
+		buf, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+
+		// keys protected by passphrase are skipped - add them to the ssh agent instead
+		signer, err := ssh.ParsePrivateKey(buf)
+		if err != nil {
+			continue
+		}
+
+		signers = append(signers, signer)
+	}
+
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	return methods
+}
+
+// sshHostKeyCallback verifies host keys against `~/.ssh/known_hosts`.
+func sshHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	knownHostsPath := filepath.Join(home, ".ssh", "known_hosts")
+
+	callback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s to verify host key - %w", knownHostsPath, err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return fmt.Errorf("host key of %s is unknown - connect once with ssh to verify and add it to %s", hostname, knownHostsPath)
+		}
+
+		return err
+	}, nil
+}
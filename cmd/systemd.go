@@ -0,0 +1,185 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IceWhaleTech/CasaOS-Common/utils/systemctl"
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/samber/lo"
+)
+
+const FlagLocal = "local"
+
+// unit name patterns of CasaOS services, including the main `casaos.service` itself
+var localServicePatterns = []string{"casaos.service", "casaos-*.service"}
+
+// localService is the state of a CasaOS service as reported by systemd on this host.
+type localService struct {
+	Name        string    `json:"name"`
+	ActiveState string    `json:"active_state"`
+	SubState    string    `json:"sub_state"`
+	Restarts    uint32    `json:"restarts"`
+	Since       time.Time `json:"since"`
+}
+
+// fallbackToLocal tells whether a healthcheck command should fall back to systemd on this host, because the CasaOS
+// API cannot be reached, as err returned by the API call.
+func fallbackToLocal(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if code, _ := exitCode(err); code != ExitCodeUnreachable {
+		return false
+	}
+
+	// systemd on this host is not the one of the remote CasaOS
+	if sshTunnel != nil {
+		return false
+	}
+
+	log.Printf("CasaOS API is unreachable (%s) - falling back to systemd on this host (use --%s to skip the API)", err.Error(), FlagLocal)
+
+	return true
+}
+
+// listLocalServices queries systemd over D-Bus for every CasaOS service unit, including those installed but not
+// loaded, e.g. a service that is disabled and has not been started since boot.
+func listLocalServices(ctx context.Context) ([]localService, error) {
+	conn, err := dbus.NewSystemdConnectionContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to systemd - %w", err)
+	}
+	defer conn.Close()
+
+	// units only loaded in memory, e.g. transient ones, have no unit file
+	loaded, err := conn.ListUnitsByPatternsContext(ctx, nil, localServicePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	unitFiles, err := conn.ListUnitFilesByPatternsContext(ctx, nil, localServicePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	names := lo.Map(loaded, func(unit dbus.UnitStatus, _ int) string { return unit.Name })
+	for _, unitFile := range unitFiles {
+		// templates like `casaos-foo@.service` are not units by themselves
+		if name := filepath.Base(unitFile.Path); !strings.HasSuffix(name, "@.service") {
+			names = append(names, name)
+		}
+	}
+
+	units, err := conn.ListUnitsByNamesContext(ctx, lo.Uniq(names))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+
+	services := make([]localService, 0, len(units))
+
+	for _, unit := range units {
+		service := localService{
+			Name:        unit.Name,
+			ActiveState: unit.ActiveState,
+			SubState:    unit.SubState,
+		}
+
+		if property, err := conn.GetServicePropertyContext(ctx, unit.Name, "NRestarts"); err == nil {
+			if restarts, ok := property.Value.Value().(uint32); ok {
+				service.Restarts = restarts
+			}
+		}
+
+		if property, err := conn.GetUnitPropertyContext(ctx, unit.Name, "StateChangeTimestamp"); err == nil {
+			if usec, ok := property.Value.Value().(uint64); ok && usec > 0 {
+				service.Since = time.UnixMicro(int64(usec))
+			}
+		}
+
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+// restartLocalService restarts a CasaOS service via systemd over D-Bus, and waits for the job to finish.
+func restartLocalService(ctx context.Context, name string) error {
+	conn, err := dbus.NewSystemdConnectionContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd - %w", err)
+	}
+	defer conn.Close()
+
+	ch := make(chan string, 1)
+	if _, err := conn.RestartUnitContext(ctx, name, "replace", ch); err != nil {
+		return err
+	}
+
+	select {
+	case result := <-ch:
+		if err, ok := systemctl.ErrorMap[result]; !ok {
+			return systemctl.ErrorUnknown
+		} else if err != nil {
+			return fmt.Errorf("failed to restart %s - %w", name, err)
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// localServiceUnitName turns a service name like `app-management` or `casaos-app-management` into its unit name.
+func localServiceUnitName(name string) string {
+	name = strings.TrimSuffix(name, ".service")
+
+	if name != "casaos" && !strings.HasPrefix(name, "casaos-") {
+		name = "casaos-" + name
+	}
+
+	return name + ".service"
+}
+
+// writeLocalJournal writes journal entries of the unit to w, read with `journalctl` on this host.
+//
+// The journal is not read with go-systemd's sdjournal, as it loads libsystemd with dlopen, which is not possible in
+// the statically linked (musl) release builds. journalctl is always available where systemd is.
+func writeLocalJournal(ctx context.Context, w io.Writer, unit string) error {
+	journalctl := exec.CommandContext(ctx, "journalctl", "--unit", unit, "--no-pager", "--output", "short-iso")
+	journalctl.Stdout = w
+
+	var stderr strings.Builder
+	journalctl.Stderr = &stderr
+
+	if err := journalctl.Run(); err != nil {
+		return fmt.Errorf("failed to read journal of %s - %s", unit, strings.TrimSpace(err.Error()+" "+stderr.String()))
+	}
+
+	return nil
+}
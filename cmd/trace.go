@@ -0,0 +1,577 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagVerbose   = "verbose"
+	FlagDebugHTTP = "debug-http"
+	FlagTraceFile = "trace-file"
+
+	// VerboseRequests logs method, url, status and latency of each HTTP request
+	VerboseRequests = 1
+
+	// VerboseHeaders also logs request and response headers
+	VerboseHeaders = 2
+
+	// VerboseBodies also logs request and response bodies, truncated
+	VerboseBodies = 3
+
+	maxLoggedBodySize = 1024
+
+	// maxTracedBodySize is how much of each body is kept for logging and trace file, so that large downloads and
+	// streaming responses are passed through without being held in memory.
+	maxTracedBodySize = 64 * 1024
+
+	redacted = "[REDACTED]"
+)
+
+// sensitiveHeaders are not logged nor written to trace file as is.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields are JSON body fields and query parameters not logged nor written to trace file as is.
+var sensitiveFields = []string{"password", "token", "access_token", "refresh_token"}
+
+// tracingTransport is a http.RoundTripper that logs requests and responses to stderr depending on verbosity,
+// and records them for trace file when enabled.
+type tracingTransport struct {
+	next      http.RoundTripper
+	verbosity int
+	logger    *log.Logger
+
+	traceFile string
+	mutex     sync.Mutex
+	entries   []*harEntry
+}
+
+var httpTrace *tracingTransport
+
+// setupHTTPTrace replaces the default HTTP transport with a tracing one, so that both `pkg/casaos`
+// and raw requests are traced, when any of the verbosity or trace flags is set.
+func setupHTTPTrace(cmd *cobra.Command) error {
+	verbosity, err := cmd.Flags().GetCount(FlagVerbose)
+	if err != nil {
+		return err
+	}
+
+	debugHTTP, err := cmd.Flags().GetBool(FlagDebugHTTP)
+	if err != nil {
+		return err
+	}
+
+	traceFile, err := cmd.Flags().GetString(FlagTraceFile)
+	if err != nil {
+		return err
+	}
+
+	if debugHTTP {
+		verbosity = VerboseBodies
+	}
+
+	if verbosity == 0 && traceFile == "" {
+		return nil
+	}
+
+	httpTrace = &tracingTransport{
+		next:      http.DefaultTransport,
+		verbosity: verbosity,
+		logger:    log.New(os.Stderr, "[http] ", log.LstdFlags|log.Lmicroseconds),
+		traceFile: traceFile,
+	}
+
+	http.DefaultTransport = httpTrace
+
+	return nil
+}
+
+// writeHTTPTrace writes recorded requests and responses to the trace file in HAR format, if enabled.
+func writeHTTPTrace() error {
+	if httpTrace == nil || httpTrace.traceFile == "" {
+		return nil
+	}
+
+	httpTrace.mutex.Lock()
+	defer httpTrace.mutex.Unlock()
+
+	har := harFile{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "casaos-cli", Version: Version},
+			Entries: httpTrace.entries,
+		},
+	}
+
+	buf, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(httpTrace.traceFile, buf, 0o600)
+}
+
+func (t *tracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// a round tripper should not modify the original request
+	request = request.Clone(request.Context())
+
+	// bodies are captured while being sent or received, instead of being read upfront
+	requestBody := &capturedBody{}
+	if request.Body != nil && request.Body != http.NoBody {
+		request.Body = &tracedBody{ReadCloser: request.Body, captured: requestBody}
+	}
+
+	requestURL := redactURL(request.URL)
+
+	if reason := retryReason(request.Context()); reason != "" && t.verbosity >= VerboseRequests {
+		t.logger.Printf("> retrying %s %s: %s", request.Method, requestURL, reason)
+	} else if t.verbosity >= VerboseRequests {
+		t.logger.Printf("> %s %s", request.Method, requestURL)
+	}
+
+	if t.verbosity >= VerboseHeaders {
+		t.logHeader(">", request.Header)
+	}
+
+	start := time.Now()
+	response, err := t.next.RoundTrip(request)
+	latency := time.Since(start)
+
+	if t.verbosity >= VerboseBodies && requestBody.size() > 0 {
+		t.logger.Printf("> %s", truncateBody(requestBody))
+	}
+
+	if err != nil {
+		if t.verbosity >= VerboseRequests {
+			t.logger.Printf("< %s %s failed after %s: %s", request.Method, requestURL, latency, err.Error())
+		}
+
+		t.recordEntry(request, requestBody, nil, start, latency)
+		return nil, err
+	}
+
+	if t.verbosity >= VerboseRequests {
+		t.logger.Printf("< %s (%s)", response.Status, latency)
+	}
+
+	if t.verbosity >= VerboseHeaders {
+		t.logHeader("<", response.Header)
+	}
+
+	entry := t.recordEntry(request, requestBody, response, start, latency)
+
+	// the body of a protocol switch, e.g. websocket, is the connection itself and must be left as is
+	if response.StatusCode == http.StatusSwitchingProtocols || response.Body == nil || response.Body == http.NoBody {
+		return response, nil
+	}
+
+	responseBody := &capturedBody{}
+	response.Body = &tracedBody{
+		ReadCloser: response.Body,
+		captured:   responseBody,
+		onClose: func() {
+			if t.verbosity >= VerboseBodies && responseBody.size() > 0 {
+				t.logger.Printf("< %s", truncateBody(responseBody))
+			}
+
+			t.recordResponseBody(entry, response, responseBody)
+		},
+	}
+
+	return response, nil
+}
+
+func (t *tracingTransport) logHeader(prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header.Values(key) {
+			t.logger.Printf("%s %s: %s", prefix, key, redactHeader(key, value))
+		}
+	}
+}
+
+// recordEntry records the request for trace file, and returns the entry so that the response body can be added
+// once it is read. It returns nil if trace file is not enabled.
+func (t *tracingTransport) recordEntry(request *http.Request, requestBody *capturedBody, response *http.Response, start time.Time, latency time.Duration) *harEntry {
+	if t.traceFile == "" {
+		return nil
+	}
+
+	entry := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            float64(latency.Microseconds()) / 1000,
+		Comment:         retryReason(request.Context()),
+		Request: harRequest{
+			Method:      request.Method,
+			URL:         redactURL(request.URL),
+			HTTPVersion: request.Proto,
+			Headers:     harHeaders(request.Header),
+			QueryString: []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    requestBody.size(),
+		},
+		Response: harResponse{
+			Headers:     []harNameValue{},
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Cache: struct{}{},
+		Timings: harTimings{
+			Send:    0,
+			Wait:    float64(latency.Microseconds()) / 1000,
+			Receive: 0,
+		},
+	}
+
+	for key, values := range request.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: key, Value: redactField(key, value)})
+		}
+	}
+
+	if requestBody.size() > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: request.Header.Get("Content-Type"),
+			Text:     requestBody.String(),
+		}
+	}
+
+	if response != nil {
+		entry.Response.Status = response.StatusCode
+		entry.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(response.Status, fmt.Sprint(response.StatusCode)))
+		entry.Response.HTTPVersion = response.Proto
+		entry.Response.Headers = harHeaders(response.Header)
+		entry.Response.Content = harContent{
+			Size:     -1,
+			MimeType: response.Header.Get("Content-Type"),
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.entries = append(t.entries, entry)
+
+	return entry
+}
+
+// recordResponseBody adds the response body to the entry, once it is read by the caller.
+func (t *tracingTransport) recordResponseBody(entry *harEntry, response *http.Response, responseBody *capturedBody) {
+	if entry == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	entry.Response.BodySize = responseBody.size()
+	entry.Response.Content.Size = responseBody.size()
+	entry.Response.Content.Text = responseBody.String()
+}
+
+// capturedBody keeps the first maxTracedBodySize bytes of a body as it is read, and counts the rest.
+type capturedBody struct {
+	mutex     sync.Mutex
+	buf       bytes.Buffer
+	total     int
+	truncated bool
+}
+
+func (c *capturedBody) Write(p []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.total += len(p)
+
+	if room := maxTracedBodySize - c.buf.Len(); room < len(p) {
+		p = p[:room]
+		c.truncated = true
+	}
+
+	c.buf.Write(p)
+}
+
+func (c *capturedBody) size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.total
+}
+
+// String returns the captured body with sensitive fields redacted.
+func (c *capturedBody) String() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	text := redactBody(c.buf.Bytes())
+	if c.truncated {
+		text += fmt.Sprintf("... (%d bytes truncated)", c.total-c.buf.Len())
+	}
+
+	return text
+}
+
+// tracedBody passes a body through as is, while capturing it. onClose is called once, when the body is closed.
+type tracedBody struct {
+	io.ReadCloser
+
+	captured  *capturedBody
+	onClose   func()
+	closeOnce sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.captured.Write(p[:n])
+	}
+
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	if b.onClose != nil {
+		b.closeOnce.Do(b.onClose)
+	}
+
+	return err
+}
+
+func truncateBody(body *capturedBody) string {
+	text := body.String()
+	if len(text) > maxLoggedBodySize {
+		return fmt.Sprintf("%s... (%d bytes truncated)", text[:maxLoggedBodySize], body.size()-maxLoggedBodySize)
+	}
+	return text
+}
+
+// redactBody replaces values of sensitive fields at any level of a JSON body. A body that is not JSON, or is cut
+// off by maxTracedBodySize, is returned as is.
+func redactBody(buf []byte) string {
+	var body interface{}
+	if err := stdjson.Unmarshal(buf, &body); err != nil {
+		return string(buf)
+	}
+
+	if !redactValue(body) {
+		return string(buf)
+	}
+
+	redactedBuf, err := stdjson.Marshal(body)
+	if err != nil {
+		return string(buf)
+	}
+
+	return string(redactedBuf)
+}
+
+// redactValue redacts sensitive fields of a decoded JSON value in place, and tells if any is found.
+func redactValue(value interface{}) bool {
+	found := false
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitiveField(key) {
+				v[key] = redacted
+				found = true
+				continue
+			}
+
+			found = redactValue(field) || found
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			found = redactValue(item) || found
+		}
+	}
+
+	return found
+}
+
+func isSensitiveField(key string) bool {
+	for _, field := range sensitiveFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactField(key, value string) string {
+	if isSensitiveField(key) {
+		return redacted
+	}
+	return value
+}
+
+// redactURL returns the url with values of sensitive query parameters redacted, e.g. a websocket token.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+
+	found := false
+	for key, values := range query {
+		if isSensitiveField(key) {
+			for i := range values {
+				values[i] = redacted
+			}
+			found = true
+		}
+	}
+
+	if !found {
+		return u.String()
+	}
+
+	redactedURL := *u
+	redactedURL.RawQuery = query.Encode()
+
+	return redactedURL.String()
+}
+
+func redactHeader(key, value string) string {
+	for _, h := range sensitiveHeaders {
+		if http.CanonicalHeaderKey(key) == h {
+			return redacted
+		}
+	}
+	return value
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for key, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: key, Value: redactHeader(key, value)})
+		}
+	}
+
+	sort.SliceStable(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+
+	return headers
+}
+
+// HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Comment         string      `json:"comment,omitempty"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type retryReasonKey struct{}
+
+// withRetry marks the context of a request as a retry of a failed one, e.g. a fallback to the service when the
+// gateway refuses connection, so that it is logged as such.
+func withRetry(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, retryReasonKey{}, reason)
+}
+
+func retryReason(ctx context.Context) string {
+	reason, _ := ctx.Value(retryReasonKey{}).(string)
+	return reason
+}
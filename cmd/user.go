@@ -16,7 +16,18 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // userCmd represents the user command
@@ -27,7 +38,11 @@ var userCmd = &cobra.Command{
 }
 
 const (
-	BasePathUsers = "v2/users"
+	BasePathUsers   = "v2/users"
+	BasePathUsersV1 = "v1/users"
+
+	FlagUserUsername      = "username"
+	FlagUserPasswordStdin = "password-stdin"
 )
 
 func init() {
@@ -43,3 +58,65 @@ func init() {
 	// is called directly, e.g.:
 	// userCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// inputReaders are shared per input of commands, so that multiple lines, e.g. current and new passwords, can be read
+// from the same stdin.
+var inputReaders = map[io.Reader]*bufio.Reader{}
+
+// readPassword reads a password from TTY without echo, or a single line from stdin of the command when it is not a
+// terminal or when fromStdin is true, so that it can be used for automation. The prompt is written to stderr of the
+// command so that it does not mix with output.
+func readPassword(cmd *cobra.Command, prompt string, fromStdin bool) (string, error) {
+	input := cmd.InOrStdin()
+
+	if fromStdin || !isTerminal(cmd) {
+		reader, ok := inputReaders[input]
+		if !ok {
+			reader = bufio.NewReader(input)
+			inputReaders[input] = reader
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(cmd.ErrOrStderr(), prompt)
+	defer fmt.Fprintln(cmd.ErrOrStderr())
+
+	password, err := term.ReadPassword(int(input.(*os.File).Fd()))
+	if err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
+
+// isTerminal tells if stdin of the command is a terminal.
+func isTerminal(cmd *cobra.Command) bool {
+	file, ok := cmd.InOrStdin().(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// userServiceV1Request sends a request to the v1 API of user service, which is where user accounts are managed.
+func userServiceV1Request(ctx context.Context, method, path, contentType string, body io.Reader) ([]byte, error) {
+	return v1Request(ctx, method, BasePathUsersV1+path, contentType, body, "casaos-user-service")
+}
+
+// userByName returns the user account with the given username.
+func userByName(ctx context.Context, username string) (jsoniter.Any, error) {
+	buf, err := userServiceV1Request(ctx, http.MethodGet, "/"+url.PathEscape(username), "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	user := json.Get(buf, "data")
+	if user.LastError() != nil || user.Get("id").LastError() != nil {
+		return nil, notFoundError{err: fmt.Errorf("user %s not found", username)}
+	}
+
+	return user, nil
+}
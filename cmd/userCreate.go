@@ -0,0 +1,118 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+// userCreateCmd represents the userCreate command
+var userCreateCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "create a user",
+	Aliases: []string{"add", "register"},
+	Long: `Create a user via user service.
+
+Note: CasaOS currently allows creating the first user only, i.e. when the system is not initialized yet.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		username, err := cmd.Flags().GetString(FlagUserUsername)
+		if err != nil {
+			return err
+		}
+
+		passwordStdin, err := cmd.Flags().GetBool(FlagUserPasswordStdin)
+		if err != nil {
+			return err
+		}
+
+		password, err := readPassword(cmd, "Password: ", passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		if !passwordStdin && isTerminal(cmd) {
+			confirmed, err := readPassword(cmd, "Confirm password: ", false)
+			if err != nil {
+				return err
+			}
+
+			if confirmed != password {
+				return fmt.Errorf("passwords do not match")
+			}
+		}
+
+		if password == "" {
+			return fmt.Errorf("password cannot be empty")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		// a one-time key is required for registration, which is only given when system is not initialized
+		buf, err := userServiceV1Request(ctx, http.MethodGet, "/status", "", nil)
+		if err != nil {
+			return err
+		}
+
+		if json.Get(buf, "data", "initialized").ToBool() {
+			return fmt.Errorf("system is already initialized with a user - creating more users is not supported")
+		}
+
+		body, err := json.Marshal(map[string]string{
+			"username": username,
+			"password": password,
+			"key":      json.Get(buf, "data", "key").ToString(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := userServiceV1Request(ctx, http.MethodPost, "/register", MINEApplicationJSON, bytes.NewReader(body)); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "user %s created\n", username)
+
+		return nil
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userCreateCmd)
+
+	userCreateCmd.Flags().String(FlagUserUsername, "", "username")
+	userCreateCmd.Flags().Bool(FlagUserPasswordStdin, false, "read password from stdin")
+
+	if err := userCreateCmd.MarkFlagRequired(FlagUserUsername); err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userCreateCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userCreateCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+// userDeleteCmd represents the userDelete command
+var userDeleteCmd = &cobra.Command{
+	Use:     "delete <username>",
+	Short:   "delete a user, or entities related to users",
+	Aliases: []string{"remove", "rm"},
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		username := cmd.Flags().Arg(0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		user, err := userByName(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		if _, err := userServiceV1Request(ctx, http.MethodDelete, fmt.Sprintf("/%d", user.Get("id").ToInt()), "", nil); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "user %s deleted\n", username)
+
+		return nil
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userDeleteCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userDeleteCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userDeleteCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagUserEventBefore = "before"
+	FlagUserEventUUID   = "uuid"
+)
+
+// userDeleteEventsCmd represents the userDeleteEvents command
+var userDeleteEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "delete events received by the user",
+	Example: `
+# delete events older than 30 days
+$ casaos-cli user delete events --before 720h
+
+# delete a specific event
+$ casaos-cli user delete events --uuid 2d4b2e1a-...`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		before, err := cmd.Flags().GetString(FlagUserEventBefore)
+		if err != nil {
+			return err
+		}
+
+		uuids, err := cmd.Flags().GetStringSlice(FlagUserEventUUID)
+		if err != nil {
+			return err
+		}
+
+		dryRun, err := cmd.Flags().GetBool(FlagDryRun)
+		if err != nil {
+			return err
+		}
+
+		if (before == "") == (len(uuids) == 0) {
+			return fmt.Errorf("either --%s or --%s should be specified", FlagUserEventBefore, FlagUserEventUUID)
+		}
+
+		client, err := casaosClient()
+		if err != nil {
+			return err
+		}
+
+		if before != "" {
+			until, err := parseEventTime(before)
+			if err != nil {
+				return err
+			}
+
+			filter := &userEventFilter{until: until}
+
+			events, err := getUserEvents(cmd.Context(), client, filter)
+			if err != nil {
+				return err
+			}
+
+			for _, event := range filter.apply(events) {
+				uuids = append(uuids, event.UUID)
+			}
+		}
+
+		if len(uuids) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No events to delete")
+			return nil
+		}
+
+		for _, uuid := range uuids {
+			if dryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "event %s would be deleted (dry run)\n", uuid)
+				continue
+			}
+
+			if err := deleteUserEvent(cmd.Context(), client, uuid); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "event %s deleted\n", uuid)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	userDeleteCmd.AddCommand(userDeleteEventsCmd)
+
+	userDeleteEventsCmd.Flags().String(FlagUserEventBefore, "", "delete events before this time (RFC3339 timestamp, or relative duration like 720h)")
+	userDeleteEventsCmd.Flags().StringSlice(FlagUserEventUUID, []string{}, "uuid of the event to delete (separated by comma)")
+	userDeleteEventsCmd.Flags().BoolP(FlagDryRun, "d", false, "dry run")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userDeleteEventsCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userDeleteEventsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+func deleteUserEvent(ctx context.Context, client *casaos.Client, uuid string) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	return client.Users().DeleteEvent(ctx, uuid)
+}
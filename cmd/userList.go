@@ -16,13 +16,57 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
 	"github.com/spf13/cobra"
 )
 
 // userListCmd represents the userList command
 var userListCmd = &cobra.Command{
 	Use:     "list",
+	Short:   "list all users, or entities related to users",
 	Aliases: []string{"ls", "get"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		buf, err := userServiceV1Request(ctx, http.MethodGet, "/name", "", nil)
+		if err != nil {
+			return err
+		}
+
+		data := json.Get(buf, "data")
+
+		usernames := []string{}
+		for i := 0; i < data.Size(); i++ {
+			user := data.Get(i)
+
+			// depending on version of user service, it could be a list of usernames or a list of users
+			username := user.Get("username")
+			if username.LastError() == nil {
+				usernames = append(usernames, username.ToString())
+				continue
+			}
+
+			usernames = append(usernames, user.ToString())
+		}
+
+		if len(usernames) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No user found")
+			return nil
+		}
+
+		sort.Strings(usernames)
+
+		for _, username := range usernames {
+			fmt.Fprintln(cmd.OutOrStdout(), username)
+		}
+
+		return nil
+	},
 }
 
 func init() {
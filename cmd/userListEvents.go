@@ -18,62 +18,154 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
-	"github.com/IceWhaleTech/CasaOS-CLI/codegen/user_service"
+	"github.com/IceWhaleTech/CasaOS-CLI/pkg/casaos"
 	"github.com/spf13/cobra"
 )
 
+const (
+	FlagUserEventSourceID = "source-id"
+	FlagUserEventName     = "name"
+	FlagUserEventSince    = "since"
+	FlagUserEventUntil    = "until"
+	FlagUserEventLimit    = "limit"
+	FlagUserEventOffset   = "offset"
+	FlagUserEventWatch    = "watch"
+	FlagUserEventInterval = "interval"
+)
+
 // userListEventsCmd represents the userListEvents command
 var userListEventsCmd = &cobra.Command{
 	Use:   "events",
 	Short: "list all events received by the user",
+	Example: `
+# list events of app management in the last 24 hours
+$ casaos-cli user list events --source-id app-management --since 24h
+
+# list the 10 latest events
+$ casaos-cli user list events --limit 10
+
+# keep showing new events as they are received
+$ casaos-cli user list events --watch`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootURL, err := rootCmd.PersistentFlags().GetString(FlagRootURL)
+		filter, err := userEventFilterFromFlags(cmd)
 		if err != nil {
 			return err
 		}
 
-		url := fmt.Sprintf("http://%s/%s", rootURL, BasePathUsers)
+		watch, err := cmd.Flags().GetBool(FlagUserEventWatch)
+		if err != nil {
+			return err
+		}
+
+		interval, err := cmd.Flags().GetDuration(FlagUserEventInterval)
+		if err != nil {
+			return err
+		}
 
-		client, err := user_service.NewClientWithResponses(url)
+		client, err := casaosClient()
 		if err != nil {
 			return err
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
-		defer cancel()
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		response, err := client.GetEventsWithResponse(ctx, &user_service.GetEventsParams{})
+		events, err := getUserEvents(ctx, client, filter)
 		if err != nil {
 			return err
 		}
 
-		if response.StatusCode() != http.StatusOK {
-			var baseResponse user_service.BaseResponse
-			if err := json.Unmarshal(response.Body, &baseResponse); err != nil {
-				return fmt.Errorf("%s - %s", response.Status(), response.Body)
+		if !watch {
+			events = filter.apply(events)
+
+			if len(events) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No events received")
+				return nil
 			}
 
-			return fmt.Errorf("%s - %s", response.Status(), *baseResponse.Message)
-		}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			defer w.Flush()
+
+			printUserEventsHeader(w)
+			printUserEvents(w, events)
 
-		if response.JSON200 == nil || len(*response.JSON200) == 0 {
-			fmt.Println("No events received")
 			return nil
 		}
 
-		for _, event := range *response.JSON200 {
-			fmt.Printf("%s\t%s\t%s\t%s\t%#v\n", event.Timestamp, event.EventUuid, event.SourceID, event.Name, event.Properties)
+		// in watch mode, paging only applies to events received before watching
+		seen := newEventSet(maxSeenEvents)
+		for _, event := range events {
+			seen.add(event.UUID)
 		}
 
-		return nil
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+
+		printUserEventsHeader(w)
+		printUserEvents(w, filter.apply(events))
+		w.Flush()
+
+		filter.limit, filter.offset = 0, 0
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			events, err := getUserEvents(ctx, client, filter)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				if !isTransientError(err) {
+					return err
+				}
+
+				fmt.Fprintf(cmd.ErrOrStderr(), "failed to get events, retrying in %s: %s\n", interval, err)
+				continue
+			}
+
+			newEvents := []casaos.UserEvent{}
+			for _, event := range events {
+				if !seen.add(event.UUID) {
+					continue
+				}
+
+				newEvents = append(newEvents, event)
+			}
+
+			printUserEvents(w, filter.apply(newEvents))
+			w.Flush()
+		}
 	},
 }
 
 func init() {
 	userListCmd.AddCommand(userListEventsCmd)
 
+	userListEventsCmd.Flags().StringP(FlagUserEventSourceID, "s", "", "only list events from this source id")
+	userListEventsCmd.Flags().StringP(FlagUserEventName, "n", "", "only list events with this name")
+	userListEventsCmd.Flags().String(FlagUserEventSince, "", "only list events since this time (RFC3339 timestamp, or relative duration like 24h)")
+	userListEventsCmd.Flags().String(FlagUserEventUntil, "", "only list events until this time (RFC3339 timestamp, or relative duration like 1h)")
+	userListEventsCmd.Flags().IntP(FlagUserEventLimit, "l", 0, "maximum number of events to list (0 means no limit)")
+	userListEventsCmd.Flags().Int(FlagUserEventOffset, 0, "number of latest events to skip")
+	userListEventsCmd.Flags().BoolP(FlagUserEventWatch, "w", false, "keep listing new events as they are received")
+	userListEventsCmd.Flags().Duration(FlagUserEventInterval, 2*time.Second, "interval of checking new events in watch mode")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -84,3 +176,190 @@ func init() {
 	// is called directly, e.g.:
 	// userListEventsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+type userEventFilter struct {
+	sourceID string
+	name     string
+	since    time.Time
+	until    time.Time
+	limit    int
+	offset   int
+}
+
+func userEventFilterFromFlags(cmd *cobra.Command) (*userEventFilter, error) {
+	filter := &userEventFilter{}
+
+	var err error
+
+	if filter.sourceID, err = cmd.Flags().GetString(FlagUserEventSourceID); err != nil {
+		return nil, err
+	}
+
+	if filter.name, err = cmd.Flags().GetString(FlagUserEventName); err != nil {
+		return nil, err
+	}
+
+	since, err := cmd.Flags().GetString(FlagUserEventSince)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.since, err = parseEventTime(since); err != nil {
+		return nil, err
+	}
+
+	until, err := cmd.Flags().GetString(FlagUserEventUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.until, err = parseEventTime(until); err != nil {
+		return nil, err
+	}
+
+	if filter.limit, err = cmd.Flags().GetInt(FlagUserEventLimit); err != nil {
+		return nil, err
+	}
+
+	if filter.offset, err = cmd.Flags().GetInt(FlagUserEventOffset); err != nil {
+		return nil, err
+	}
+
+	if filter.limit < 0 || filter.offset < 0 {
+		return nil, fmt.Errorf("limit and offset must not be negative")
+	}
+
+	return filter, nil
+}
+
+// apply returns events matching the filter, sorted from oldest to latest. Offset and limit are
+// counted from the latest event.
+func (f *userEventFilter) apply(events []casaos.UserEvent) []casaos.UserEvent {
+	result := []casaos.UserEvent{}
+
+	for _, event := range events {
+		if f.sourceID != "" && event.SourceID != f.sourceID {
+			continue
+		}
+
+		if f.name != "" && event.Name != f.name {
+			continue
+		}
+
+		if !f.since.IsZero() && event.Timestamp.Before(f.since) {
+			continue
+		}
+
+		if !f.until.IsZero() && event.Timestamp.After(f.until) {
+			continue
+		}
+
+		result = append(result, event)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	end := len(result) - f.offset
+	if end < 0 {
+		end = 0
+	}
+
+	start := 0
+	if f.limit > 0 && end-f.limit > 0 {
+		start = end - f.limit
+	}
+
+	return result[start:end]
+}
+
+// parseEventTime accepts either an RFC3339 timestamp, or a duration relative to now.
+func parseEventTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, should be a RFC3339 timestamp like %s, or a duration like 24h", value, time.Now().Format(time.RFC3339))
+	}
+
+	return t, nil
+}
+
+// request is the filter for the server, so that it only returns matching events. The filter is still applied to
+// the response, in case the server ignores any of it.
+func (f *userEventFilter) request() casaos.UserEventsFilter {
+	return casaos.UserEventsFilter{
+		SourceID: f.sourceID,
+		Name:     f.name,
+		Since:    f.since,
+		Until:    f.until,
+	}
+}
+
+// maxSeenEvents is how many event uuids watch mode remembers, so memory does not grow while watching for days.
+const maxSeenEvents = 10000
+
+// eventSet is a set of event uuids, which forgets the oldest uuid when it is full.
+type eventSet struct {
+	uuids map[string]bool
+	order []string
+	size  int
+}
+
+func newEventSet(size int) *eventSet {
+	return &eventSet{uuids: map[string]bool{}, size: size}
+}
+
+// add adds the uuid to the set, and returns false if it is already in the set.
+func (s *eventSet) add(uuid string) bool {
+	if s.uuids[uuid] {
+		return false
+	}
+
+	if len(s.order) >= s.size {
+		delete(s.uuids, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	s.uuids[uuid] = true
+	s.order = append(s.order, uuid)
+
+	return true
+}
+
+func getUserEvents(ctx context.Context, client *casaos.Client, filter *userEventFilter) ([]casaos.UserEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	return client.Users().Events(ctx, filter.request())
+}
+
+func printUserEventsHeader(w io.Writer) {
+	fmt.Fprintln(w, "TIMESTAMP\tUUID\tSOURCE ID\tNAME\tPROPERTIES")
+	fmt.Fprintln(w, "---------\t----\t---------\t----\t----------")
+}
+
+func printUserEvents(w io.Writer, events []casaos.UserEvent) {
+	for _, event := range events {
+		properties := []string{}
+		for key, value := range event.Properties {
+			properties = append(properties, fmt.Sprintf("%s=%v", key, value))
+		}
+		sort.Strings(properties)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			event.Timestamp.Local().Format(time.RFC3339),
+			event.UUID,
+			event.SourceID,
+			event.Name,
+			trim(strings.Join(properties, ", "), 120),
+		)
+	}
+}
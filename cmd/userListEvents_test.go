@@ -0,0 +1,39 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserListEvents(t *testing.T) {
+	// timestamps are printed in local time, which is restored after the mock is closed
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	server := newMockCasaOS(t)
+
+	stdout, stderr, code := runCommand(t, server, "user", "list", "events")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "user_list_events", stdout)
+
+	// the mock ignores the query string, so the filter is applied to the response as well
+	stdout, stderr, code = runCommand(t, server, "user", "list", "events", "--source-id", "app-management")
+	assertExitCode(t, ExitCodeOK, code, stderr)
+	assertGolden(t, "user_list_events_source_id", stdout)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+// userLoginCmd represents the userLogin command
+var userLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "login as a user and print the access token",
+	Example: `
+# login interactively
+$ export CASAOS_TOKEN=$(casaos-cli user login --username admin)
+
+# login non-interactively
+$ echo "$PASSWORD" | casaos-cli user login --username admin --password-stdin
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		username, err := cmd.Flags().GetString(FlagUserUsername)
+		if err != nil {
+			return err
+		}
+
+		passwordStdin, err := cmd.Flags().GetBool(FlagUserPasswordStdin)
+		if err != nil {
+			return err
+		}
+
+		password, err := readPassword(cmd, "Password: ", passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(map[string]string{
+			"username": username,
+			"password": password,
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		buf, err := userServiceV1Request(ctx, http.MethodPost, "/login", MINEApplicationJSON, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		token := json.Get(buf, "data", "token", "access_token")
+		if token.LastError() != nil || token.ToString() == "" {
+			return fmt.Errorf("no access token in response")
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), token.ToString())
+
+		return nil
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userLoginCmd)
+
+	userLoginCmd.Flags().String(FlagUserUsername, "", "username")
+	userLoginCmd.Flags().Bool(FlagUserPasswordStdin, false, "read password from stdin")
+
+	if err := userLoginCmd.MarkFlagRequired(FlagUserUsername); err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userLoginCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userLoginCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// userSetAvatarCmd represents the userSetAvatar command
+var userSetAvatarCmd = &cobra.Command{
+	Use:   "avatar",
+	Short: "upload an image as avatar of the current user",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		imagePath := cmd.Flag(FlagFile).Value.String()
+
+		file, err := os.Open(imagePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		var body bytes.Buffer
+
+		writer := multipart.NewWriter(&body)
+
+		part, err := writer.CreateFormFile("file", filepath.Base(imagePath))
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(part, file); err != nil {
+			return err
+		}
+
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		if _, err := userServiceV1Request(ctx, http.MethodPost, "/avatar", writer.FormDataContentType(), &body); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "avatar updated")
+
+		return nil
+	},
+}
+
+func init() {
+	userSetCmd.AddCommand(userSetAvatarCmd)
+
+	userSetAvatarCmd.Flags().StringP(FlagFile, "f", "", "path to an image file")
+	if err := userSetAvatarCmd.MarkFlagRequired(FlagFile); err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userSetAvatarCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userSetAvatarCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+// userSetPasswordCmd represents the userSetPassword command
+var userSetPasswordCmd = &cobra.Command{
+	Use:   "password",
+	Short: "change password of the current user",
+	Long: `Change password of the current user, i.e. the user of the access token.
+
+When reading from stdin, the first line is the current password and the second line is the new password.`,
+	Example: `
+$ printf '%s\n%s\n' "$OLD_PASSWORD" "$NEW_PASSWORD" | casaos-cli user set password --password-stdin
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passwordStdin, err := cmd.Flags().GetBool(FlagUserPasswordStdin)
+		if err != nil {
+			return err
+		}
+
+		oldPassword, err := readPassword(cmd, "Current password: ", passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		password, err := readPassword(cmd, "New password: ", passwordStdin)
+		if err != nil {
+			return err
+		}
+
+		if !passwordStdin && isTerminal(cmd) {
+			confirmed, err := readPassword(cmd, "Confirm new password: ", false)
+			if err != nil {
+				return err
+			}
+
+			if confirmed != password {
+				return fmt.Errorf("passwords do not match")
+			}
+		}
+
+		if password == "" {
+			return fmt.Errorf("password cannot be empty")
+		}
+
+		body, err := json.Marshal(map[string]string{
+			"old_password": oldPassword,
+			"password":     password,
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		if _, err := userServiceV1Request(ctx, http.MethodPut, "/current/password", MINEApplicationJSON, bytes.NewReader(body)); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "password changed")
+
+		return nil
+	},
+}
+
+func init() {
+	userSetCmd.AddCommand(userSetPasswordCmd)
+
+	userSetPasswordCmd.Flags().Bool(FlagUserPasswordStdin, false, "read current and new passwords from stdin, one per line")
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userSetPasswordCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userSetPasswordCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
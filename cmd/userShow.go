@@ -0,0 +1,81 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// userShowCmd represents the userShow command
+var userShowCmd = &cobra.Command{
+	Use:   "show <username>",
+	Short: "show information of a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		defer cancel()
+
+		user, err := userByName(ctx, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		defer w.Flush()
+
+		for _, field := range []struct {
+			name string
+			key  string
+		}{
+			{"ID", "id"},
+			{"Username", "username"},
+			{"Nickname", "nickname"},
+			{"Email", "email"},
+			{"Role", "role"},
+			{"Description", "description"},
+			{"Avatar", "avatar"},
+			{"Created At", "created_at"},
+			{"Updated At", "updated_at"},
+		} {
+			value := user.Get(field.key)
+			if value.LastError() != nil || value.ToString() == "" {
+				continue
+			}
+
+			fmt.Fprintf(w, "%s:\t%s\n", field.name, value.ToString())
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userShowCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// userShowCmd.PersistentFlags().String("foo", "", "A help for foo")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// userShowCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,40 @@
+/*
+Copyright © 2023 IceWhaleTech
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestReadPasswordFromStdin(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("current\r\nnew\n"))
+
+	// lines are read from the same stdin of the command, one by each call
+	for _, expected := range []string{"current", "new", ""} {
+		password, err := readPassword(cmd, "Password: ", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if password != expected {
+			t.Errorf("expected password %q, got %q", expected, password)
+		}
+	}
+}
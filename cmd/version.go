@@ -25,9 +25,11 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(Version)
-		fmt.Printf("(build time: %s, commit: %s)\n", Date, Commit)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(cmd.OutOrStdout(), Version)
+		fmt.Fprintf(cmd.OutOrStdout(), "(build time: %s, commit: %s)\n", Date, Commit)
+
+		return nil
 	},
 }
 
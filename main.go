@@ -1,8 +1,8 @@
-//go:generate bash -c "mkdir -p codegen/app_management && go run github.com/deepmap/oapi-codegen/cmd/oapi-codegen@v1.12.4 -generate types,client -package app_management https://raw.githubusercontent.com/IceWhaleTech/CasaOS-AppManagement/main/api/app_management/openapi.yaml > codegen/app_management/api.go"
-//go:generate bash -c "mkdir -p codegen/casaos && go run github.com/deepmap/oapi-codegen/cmd/oapi-codegen@v1.12.4 -generate types,client -package casaos https://raw.githubusercontent.com/IceWhaleTech/CasaOS/main/api/casaos/openapi.yaml > codegen/casaos/api.go"
-//go:generate bash -c "mkdir -p codegen/local_storage && go run github.com/deepmap/oapi-codegen/cmd/oapi-codegen@v1.12.4 -generate types,client -package local_storage https://raw.githubusercontent.com/IceWhaleTech/CasaOS-LocalStorage/main/api/local_storage/openapi.yaml > codegen/local_storage/api.go"
-//go:generate bash -c "mkdir -p codegen/message_bus && go run github.com/deepmap/oapi-codegen/cmd/oapi-codegen@v1.12.4 -generate types,client -package message_bus https://raw.githubusercontent.com/IceWhaleTech/CasaOS-MessageBus/main/api/message_bus/openapi.yaml > codegen/message_bus/api.go"
-//go:generate bash -c "mkdir -p codegen/user_service && go run github.com/deepmap/oapi-codegen/cmd/oapi-codegen@v1.12.4 -generate types,client -package user_service https://raw.githubusercontent.com/IceWhaleTech/CasaOS-UserService/main/api/user-service/openapi.yaml > codegen/user_service/api.go"
+//go:generate bash -c "mkdir -p cmd/openapi && curl -fsSL -o cmd/openapi/app_management.yaml https://raw.githubusercontent.com/IceWhaleTech/CasaOS-AppManagement/main/api/app_management/openapi.yaml"
+//go:generate bash -c "mkdir -p cmd/openapi && curl -fsSL -o cmd/openapi/casaos.yaml https://raw.githubusercontent.com/IceWhaleTech/CasaOS/main/api/casaos/openapi.yaml"
+//go:generate bash -c "mkdir -p cmd/openapi && curl -fsSL -o cmd/openapi/local_storage.yaml https://raw.githubusercontent.com/IceWhaleTech/CasaOS-LocalStorage/main/api/local_storage/openapi.yaml"
+//go:generate bash -c "mkdir -p cmd/openapi && curl -fsSL -o cmd/openapi/message_bus.yaml https://raw.githubusercontent.com/IceWhaleTech/CasaOS-MessageBus/main/api/message_bus/openapi.yaml"
+//go:generate bash -c "mkdir -p cmd/openapi && curl -fsSL -o cmd/openapi/user_service.yaml https://raw.githubusercontent.com/IceWhaleTech/CasaOS-UserService/main/api/user-service/openapi.yaml"
 
 /*
 Copyright © 2022 IceWhaleTech
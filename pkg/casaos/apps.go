@@ -0,0 +1,616 @@
+package casaos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mitchellh/mapstructure"
+	"github.com/samber/lo"
+)
+
+const (
+	DefaultLanguage = "en_us"
+
+	serviceAppManagement = "casaos-app-management"
+)
+
+// AppsService manages compose apps via the app management service.
+type AppsService struct {
+	client *Client
+}
+
+// App is a locally installed compose app.
+type App struct {
+	ID     string
+	Status string
+	Images []string
+
+	// CasaOSApp is false if the app has no store info, i.e. it is not a CasaOS compose app.
+	CasaOSApp bool
+
+	// WebUI is the url to the web UI of the app, which is empty if the app is not a CasaOS compose app.
+	WebUI string
+
+	// PortMap is the host port of the web UI, from store info of the app.
+	PortMap string
+
+	// Description of the app, by language.
+	Description map[string]string
+
+	// Services of the app, sorted by name.
+	Services []AppService
+}
+
+// InstallOptions are options for installing a compose app.
+type InstallOptions struct {
+	DryRun bool
+}
+
+// ApplyOptions are options for applying changes to an installed compose app.
+type ApplyOptions struct {
+	DryRun bool
+}
+
+// UninstallOptions are options for uninstalling a compose app.
+type UninstallOptions struct {
+	// KeepConfig keeps the config folder of the app, e.g. `/DATA/AppData/<appid>`, which is deleted by default.
+	KeepConfig bool
+}
+
+// UpdateOptions are options for updating a compose app to the latest version in app store.
+type UpdateOptions struct {
+	// Force pulls images and recreates containers even if the app is already up to date.
+	Force bool
+}
+
+// AppDetail is a locally installed compose app, with its store info.
+type AppDetail struct {
+	App
+
+	// Title and Tips (shown before install) of the app, by language.
+	Title map[string]string
+	Tips  map[string]string
+
+	Author    string
+	Developer string
+	Category  string
+
+	// Main is the name of the main service of the app.
+	Main string
+}
+
+// AppService is a service in the compose file of an app.
+type AppService struct {
+	Name        string
+	Image       string
+	Ports       []AppPort
+	Volumes     []AppVolume
+	Environment map[string]string
+}
+
+// AppPort is a port published by a service.
+type AppPort struct {
+	// Published is the host port, or a range of them, e.g. `8080` or `8080-8090`. It is empty if the port is
+	// published on a random host port.
+	Published string
+	Target    string
+	Protocol  string
+}
+
+// AppVolume is a volume mounted by a service.
+type AppVolume struct {
+	Type   string
+	Source string
+	Target string
+}
+
+// Container is a container of a compose app.
+type Container struct {
+	ID    string
+	Name  string
+	Image string
+	State string
+
+	// Status is the human readable status from Docker, e.g. `Up 2 hours (healthy)`.
+	Status string
+
+	// Health is the result of the container healthcheck, e.g. `healthy`, or empty if there is none.
+	Health string
+
+	// Main is true if the container is of the main service of the app.
+	Main bool
+}
+
+// GlobalSetting is a global environment variable passed to each container of every compose app.
+type GlobalSetting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// StoreInfo is store info of a compose app, i.e. its `x-casaos` extension, as `store_info` of an installed app.
+type StoreInfo struct {
+	Main           string            `json:"main,omitempty" mapstructure:"main"`
+	Architectures  []string          `json:"architectures,omitempty" mapstructure:"architectures"`
+	Author         string            `json:"author,omitempty" mapstructure:"author"`
+	Developer      string            `json:"developer,omitempty" mapstructure:"developer"`
+	Category       string            `json:"category,omitempty" mapstructure:"category"`
+	Icon           string            `json:"icon,omitempty" mapstructure:"icon"`
+	Thumbnail      string            `json:"thumbnail,omitempty" mapstructure:"thumbnail"`
+	ScreenshotLink []string          `json:"screenshot_link,omitempty" mapstructure:"screenshot_link"`
+	Scheme         string            `json:"scheme,omitempty" mapstructure:"scheme"`
+	Hostname       string            `json:"hostname,omitempty" mapstructure:"hostname"`
+	PortMap        string            `json:"port_map,omitempty" mapstructure:"port_map"`
+	Index          string            `json:"index,omitempty" mapstructure:"index"`
+	Title          map[string]string `json:"title,omitempty" mapstructure:"title"`
+	Tagline        map[string]string `json:"tagline,omitempty" mapstructure:"tagline"`
+	Description    map[string]string `json:"description,omitempty" mapstructure:"description"`
+	Tips           StoreInfoTips     `json:"tips,omitempty" mapstructure:"tips"`
+}
+
+// StoreInfoTips are tips shown to the user, by language.
+type StoreInfoTips struct {
+	BeforeInstall map[string]string `json:"before_install,omitempty" mapstructure:"before_install"`
+}
+
+// DescriptionIn returns the description in the given language, or in any available language if not found.
+func (a App) DescriptionIn(language string) string {
+	return Localized(a.Description, language)
+}
+
+// Localized returns the value in the given language, falling back to DefaultLanguage and then any available language.
+func Localized(values map[string]string, language string) string {
+	for _, l := range []string{language, DefaultLanguage} {
+		if value := values[l]; value != "" {
+			return value
+		}
+	}
+
+	languages := lo.Keys(values)
+	sort.Strings(languages)
+
+	for _, l := range languages {
+		if value := values[l]; value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (s *AppsService) path(elem ...string) string {
+	path := BasePathAppManagement
+	for _, e := range elem {
+		path += "/" + url.PathEscape(e)
+	}
+	return path
+}
+
+// List returns locally installed compose apps, sorted by ID.
+func (s *AppsService) List(ctx context.Context) ([]App, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: s.path("compose"), service: serviceAppManagement})
+	if err != nil {
+		return nil, err
+	}
+
+	// the compose app is parsed as raw JSON, as it cannot be unmarshalled to a typed struct - see
+	// https://github.com/compose-spec/compose-go/issues/353
+	data := json.Get(buf, "data")
+
+	ids := data.Keys()
+	sort.Strings(ids)
+
+	apps := make([]App, 0, len(ids))
+
+	for _, id := range ids {
+		apps = append(apps, parseApp(id, data.Get(id), s.client.hostname))
+	}
+
+	return apps, nil
+}
+
+// Get returns a locally installed compose app, with its store info and services.
+func (s *AppsService) Get(ctx context.Context, appID string) (*AppDetail, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: s.path("compose", appID), service: serviceAppManagement})
+	if err != nil {
+		return nil, err
+	}
+
+	data := json.Get(buf, "data")
+	if data.LastError() != nil {
+		return nil, fmt.Errorf("body does not contain `data`")
+	}
+
+	storeInfo := data.Get("store_info")
+
+	return &AppDetail{
+		App:       parseApp(appID, data, s.client.hostname),
+		Title:     languageMap(storeInfo.Get("title")),
+		Tips:      languageMap(storeInfo.Get("tips", "before_install")),
+		Author:    storeInfo.Get("author").ToString(),
+		Developer: storeInfo.Get("developer").ToString(),
+		Category:  storeInfo.Get("category").ToString(),
+		Main:      storeInfo.Get("main").ToString(),
+	}, nil
+}
+
+// Compose returns the compose YAML of a locally installed compose app, with its store info in `x-casaos`.
+func (s *AppsService) Compose(ctx context.Context, appID string) ([]byte, error) {
+	return s.client.do(ctx, apiRequest{
+		method:  http.MethodGet,
+		path:    s.path("compose", appID),
+		accept:  mimeApplicationYAML,
+		service: serviceAppManagement,
+	})
+}
+
+// Containers returns containers of a locally installed compose app, sorted by name.
+func (s *AppsService) Containers(ctx context.Context, appID string) ([]Container, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: s.path("compose", appID, "containers"), service: serviceAppManagement})
+	if err != nil {
+		return nil, err
+	}
+
+	main := json.Get(buf, "data", "main").ToString()
+	data := json.Get(buf, "data", "containers")
+
+	containers := []Container{}
+
+	for _, id := range data.Keys() {
+		container := parseContainer(data.Get(id))
+		if container.ID == "" {
+			container.ID = id
+		}
+		container.Main = id == main
+
+		containers = append(containers, container)
+	}
+
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+
+	return containers, nil
+}
+
+// Install installs a compose app from its compose YAML, and returns the message from the server.
+func (s *AppsService) Install(ctx context.Context, composeYAML []byte, opts InstallOptions) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:      http.MethodPost,
+		path:        s.path("compose"),
+		query:       url.Values{"dry_run": {strconv.FormatBool(opts.DryRun)}},
+		body:        bytes.NewReader(composeYAML),
+		contentType: mimeApplicationYAML,
+		service:     serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Apply applies changes in the compose YAML to a locally installed compose app, and returns the message from the
+// server.
+func (s *AppsService) Apply(ctx context.Context, appID string, composeYAML []byte, opts ApplyOptions) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:      http.MethodPut,
+		path:        s.path("compose", appID),
+		query:       url.Values{"dry_run": {strconv.FormatBool(opts.DryRun)}},
+		body:        bytes.NewReader(composeYAML),
+		contentType: mimeApplicationYAML,
+		service:     serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Uninstall uninstalls a locally installed compose app, and returns the message from the server.
+func (s *AppsService) Uninstall(ctx context.Context, appID string, opts UninstallOptions) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:  http.MethodDelete,
+		path:    s.path("compose", appID),
+		query:   url.Values{"delete_config_folder": {strconv.FormatBool(!opts.KeepConfig)}},
+		service: serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Update updates a locally installed compose app to the latest version in app store, and returns the message from
+// the server.
+func (s *AppsService) Update(ctx context.Context, appID string, opts UpdateOptions) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:  http.MethodPatch,
+		path:    s.path("compose", appID),
+		query:   url.Values{"force": {strconv.FormatBool(opts.Force)}},
+		service: serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Start starts a compose app, and returns the message from the server.
+func (s *AppsService) Start(ctx context.Context, appID string) (string, error) {
+	return s.setStatus(ctx, appID, "start")
+}
+
+// Stop stops a compose app, and returns the message from the server.
+func (s *AppsService) Stop(ctx context.Context, appID string) (string, error) {
+	return s.setStatus(ctx, appID, "stop")
+}
+
+// Restart restarts a compose app, and returns the message from the server.
+func (s *AppsService) Restart(ctx context.Context, appID string) (string, error) {
+	return s.setStatus(ctx, appID, "restart")
+}
+
+func (s *AppsService) setStatus(ctx context.Context, appID, status string) (string, error) {
+	body, err := jsonBody(status)
+	if err != nil {
+		return "", err
+	}
+
+	buf, err := s.client.do(ctx, apiRequest{
+		method:      http.MethodPut,
+		path:        s.path("compose", appID, "status"),
+		body:        body,
+		contentType: mimeApplicationJSON,
+		service:     serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Logs returns the last lines of logs of a compose app.
+func (s *AppsService) Logs(ctx context.Context, appID string, lines int) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:  http.MethodGet,
+		path:    s.path("compose", appID, "logs"),
+		query:   url.Values{"lines": {strconv.Itoa(lines)}},
+		service: serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return json.Get(buf, "data").ToString(), nil
+}
+
+// ConvertAppFile converts a legacy `appfile.json` to compose YAML.
+func (s *AppsService) ConvertAppFile(ctx context.Context, appFileJSON []byte) ([]byte, error) {
+	return s.client.do(ctx, apiRequest{
+		method:      http.MethodPost,
+		path:        s.path("convert"),
+		query:       url.Values{"type": {"appfile"}},
+		body:        bytes.NewReader(appFileJSON),
+		contentType: mimeApplicationJSON,
+		service:     serviceAppManagement,
+	})
+}
+
+// GlobalSettings returns global environment variables.
+func (s *AppsService) GlobalSettings(ctx context.Context) ([]GlobalSetting, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: s.path("global"), service: serviceAppManagement})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data []GlobalSetting `json:"data"`
+	}
+
+	if err := json.Unmarshal(buf, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Data == nil {
+		return []GlobalSetting{}, nil
+	}
+
+	return response.Data, nil
+}
+
+// SetGlobalSetting sets a global environment variable, and returns it as it is stored.
+func (s *AppsService) SetGlobalSetting(ctx context.Context, key, value string) (*GlobalSetting, error) {
+	body, err := jsonBody(GlobalSetting{Key: key, Value: value})
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := s.client.do(ctx, apiRequest{
+		method:      http.MethodPut,
+		path:        s.path("global", key),
+		body:        body,
+		contentType: mimeApplicationJSON,
+		service:     serviceAppManagement,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data *GlobalSetting `json:"data"`
+	}
+
+	if err := json.Unmarshal(buf, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Data == nil {
+		return &GlobalSetting{Key: key, Value: value}, nil
+	}
+
+	return response.Data, nil
+}
+
+func parseApp(id string, app jsoniter.Any, host string) App {
+	a := App{
+		ID:       id,
+		Status:   app.Get("status").ToString(),
+		Images:   []string{},
+		Services: []AppService{},
+	}
+
+	services := app.Get("compose", "services")
+
+	names := services.Keys()
+	sort.Strings(names)
+
+	for _, name := range names {
+		service := parseAppService(name, services.Get(name))
+		if service.Image != "" {
+			a.Images = append(a.Images, service.Image)
+		}
+
+		a.Services = append(a.Services, service)
+	}
+
+	storeInfo := app.Get("store_info")
+	if storeInfo.LastError() == nil {
+		a.CasaOSApp = true
+		a.PortMap = storeInfo.Get("port_map").ToString()
+		a.WebUI = WebUIURL(
+			storeInfo.Get("scheme").ToString(),
+			lo.If(storeInfo.Get("hostname").ToString() != "", storeInfo.Get("hostname").ToString()).Else(host),
+			a.PortMap,
+			storeInfo.Get("index").ToString(),
+		)
+
+		a.Description = languageMap(storeInfo.Get("description"))
+	}
+
+	return a
+}
+
+func parseAppService(name string, service jsoniter.Any) AppService {
+	appService := AppService{
+		Name:        name,
+		Image:       service.Get("image").ToString(),
+		Environment: map[string]string{},
+	}
+
+	ports := service.Get("ports")
+	for i := 0; i < ports.Size(); i++ {
+		port := ports.Get(i)
+		appService.Ports = append(appService.Ports, AppPort{
+			Published: port.Get("published").ToString(),
+			Target:    port.Get("target").ToString(),
+			Protocol:  lo.If(port.Get("protocol").ToString() != "", strings.ToLower(port.Get("protocol").ToString())).Else("tcp"),
+		})
+	}
+
+	volumes := service.Get("volumes")
+	for i := 0; i < volumes.Size(); i++ {
+		volume := volumes.Get(i)
+		appService.Volumes = append(appService.Volumes, AppVolume{
+			Type:   volume.Get("type").ToString(),
+			Source: volume.Get("source").ToString(),
+			Target: volume.Get("target").ToString(),
+		})
+	}
+
+	environment := service.Get("environment")
+	for _, key := range environment.Keys() {
+		appService.Environment[key] = environment.Get(key).ToString()
+	}
+
+	return appService
+}
+
+// parseContainer parses a container summary, with field names matched case-insensitively as they differ between
+// versions of app management.
+func parseContainer(container jsoniter.Any) Container {
+	fields := map[string]jsoniter.Any{}
+	for _, key := range container.Keys() {
+		fields[strings.ToLower(key)] = container.Get(key)
+	}
+
+	field := func(name string) string {
+		if value, ok := fields[name]; ok {
+			return value.ToString()
+		}
+		return ""
+	}
+
+	c := Container{
+		ID:     field("id"),
+		Name:   strings.TrimPrefix(field("name"), "/"),
+		Image:  field("image"),
+		State:  field("state"),
+		Status: field("status"),
+		Health: field("health"),
+	}
+
+	// e.g. `Up 2 hours (healthy)`
+	if c.Health == "" && strings.HasPrefix(c.Status, "Up ") {
+		if start, end := strings.LastIndex(c.Status, "("), strings.LastIndex(c.Status, ")"); start >= 0 && end > start {
+			c.Health = strings.TrimPrefix(c.Status[start+1:end], "health: ")
+		}
+	}
+
+	return c
+}
+
+func languageMap(value jsoniter.Any) map[string]string {
+	values := map[string]string{}
+	for _, language := range value.Keys() {
+		values[language] = value.Get(language).ToString()
+	}
+	return values
+}
+
+// WebUIURL builds the url to the web UI of an app from its store info, with `http` as default scheme.
+func WebUIURL(scheme, hostname, portMap, index string) string {
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	if portMap == "" {
+		portMap = "unknown"
+	}
+
+	return fmt.Sprintf("%s://%s:%s/%s", scheme, hostname, portMap, strings.TrimLeft(index, "/"))
+}
+
+// ComposeAppStoreInfo decodes store info from a compose app decoded as map, i.e. from the `store_info` key.
+func ComposeAppStoreInfo(composeApp interface{}) (*StoreInfo, error) {
+	composeAppMapStruct, ok := composeApp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("app is not a map[string]interface{}")
+	}
+
+	_, ok = composeAppMapStruct["store_info"]
+	if !ok {
+		return nil, fmt.Errorf("app does not have \"store_info\"")
+	}
+
+	composeAppStoreInfoMapStruct, ok := composeAppMapStruct["store_info"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("app[\"store_info\"] is not a map[string]interface{}")
+	}
+
+	composeAppStoreInfo := &StoreInfo{}
+
+	if err := mapstructure.WeakDecode(composeAppStoreInfoMapStruct, composeAppStoreInfo); err != nil {
+		return nil, err
+	}
+
+	return composeAppStoreInfo, nil
+}
@@ -0,0 +1,248 @@
+package casaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	MessageTypeEvent  = "event"
+	MessageTypeAction = "action"
+
+	DefaultMessageBufferSize = 1024
+
+	serviceMessageBus = "casaos-message-bus"
+)
+
+// Event is a message received from message bus, i.e. an event or an action.
+type Event struct {
+	// SourceID is the source of the message, e.g. `local-storage`.
+	SourceID string `json:"sourceID"`
+
+	// Name of the event or action, e.g. `local-storage:disk:added`.
+	Name string `json:"name"`
+
+	Properties map[string]string `json:"properties"`
+
+	// UUID and Timestamp are empty if not set by the source.
+	UUID      string     `json:"uuid,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// MessageType is an event or action type registered in message bus by its source.
+type MessageType struct {
+	SourceID string `json:"sourceID"`
+	Name     string `json:"name"`
+
+	PropertyTypes []PropertyType `json:"propertyTypeList"`
+}
+
+// PropertyType is a property that messages of a type carry.
+type PropertyType struct {
+	Name string `json:"name"`
+}
+
+// BusService subscribes to messages in message bus, and lists and triggers them.
+type BusService struct {
+	client *Client
+}
+
+// Filter selects messages to subscribe to.
+type Filter struct {
+	// SourceID is the source of messages, e.g. `local-storage`. It is required.
+	SourceID string
+
+	// Names of events or actions to subscribe to. All of them if empty.
+	Names []string
+
+	// MessageType is either MessageTypeEvent (default) or MessageTypeAction.
+	MessageType string
+
+	// BufferSize is the max size of a single message. DefaultMessageBufferSize is used if zero.
+	BufferSize uint
+}
+
+// Subscribe subscribes to messages via websocket, and returns a channel of them. The channel is closed when ctx
+// is done or the connection is lost.
+func (s *BusService) Subscribe(ctx context.Context, filter Filter) (<-chan Event, error) {
+	ws, err := s.dial(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		_ = s.receive(ctx, ws, filter, func(event Event) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return events, nil
+}
+
+// SubscribeFunc subscribes to messages via websocket, and calls handler for each of them until ctx is done,
+// the connection is lost, or the handler returns an error, which is then returned.
+func (s *BusService) SubscribeFunc(ctx context.Context, filter Filter, handler func(event Event) error) error {
+	ws, err := s.dial(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return s.receive(ctx, ws, filter, handler)
+}
+
+// EventTypes returns event types registered in message bus.
+func (s *BusService) EventTypes(ctx context.Context) ([]MessageType, error) {
+	return s.types(ctx, "event_type")
+}
+
+// ActionTypes returns action types registered in message bus.
+func (s *BusService) ActionTypes(ctx context.Context) ([]MessageType, error) {
+	return s.types(ctx, "action_type")
+}
+
+// TriggerAction triggers an action of source, e.g. `local-storage`, with properties.
+func (s *BusService) TriggerAction(ctx context.Context, sourceID, name string, properties map[string]string) error {
+	if properties == nil {
+		properties = map[string]string{}
+	}
+
+	body, err := jsonBody(properties)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.do(ctx, apiRequest{
+		method:      http.MethodPost,
+		path:        BasePathMessageBus + "/action/" + url.PathEscape(sourceID) + "/" + url.PathEscape(name),
+		body:        body,
+		contentType: mimeApplicationJSON,
+		service:     serviceMessageBus,
+	})
+
+	return err
+}
+
+func (s *BusService) types(ctx context.Context, name string) ([]MessageType, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathMessageBus + "/" + name, service: serviceMessageBus})
+	if err != nil {
+		return nil, err
+	}
+
+	types := []MessageType{}
+	if err := json.Unmarshal(buf, &types); err != nil {
+		return nil, err
+	}
+
+	return types, nil
+}
+
+// URL returns the websocket url for the filter.
+func (s *BusService) URL(filter Filter) string {
+	messageType := filter.MessageType
+	if messageType == "" {
+		messageType = MessageTypeEvent
+	}
+
+	wsURL := fmt.Sprintf("ws://%s/%s/%s/%s", s.client.rootURL, BasePathMessageBus, messageType, filter.SourceID)
+	if len(filter.Names) > 0 {
+		wsURL += "?names=" + url.QueryEscape(strings.Join(filter.Names, ","))
+	}
+
+	return wsURL
+}
+
+func (s *BusService) dial(ctx context.Context, filter Filter) (*websocket.Conn, error) {
+	if filter.SourceID == "" {
+		return nil, errors.New("source id is required")
+	}
+
+	config, err := websocket.NewConfig(s.URL(filter), "http://localhost")
+	if err != nil {
+		return nil, err
+	}
+
+	if s.client.token != "" {
+		config.Header.Set("Authorization", s.client.token)
+	}
+
+	if s.client.dialContext == nil {
+		return websocket.DialConfig(config)
+	}
+
+	address := config.Location.Host
+	if config.Location.Port() == "" {
+		address = net.JoinHostPort(config.Location.Hostname(), "80")
+	}
+
+	conn, err := s.client.dialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func (s *BusService) receive(ctx context.Context, ws *websocket.Conn, filter Filter, handler func(event Event) error) error {
+	defer ws.Close()
+
+	// unblock reading when ctx is done
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-done:
+		}
+	}()
+
+	bufferSize := filter.BufferSize
+	if bufferSize == 0 {
+		bufferSize = DefaultMessageBufferSize
+	}
+
+	for {
+		msg := make([]byte, bufferSize)
+		n, err := ws.Read(msg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal(msg[:n], &event); err != nil {
+			// skip messages that are not events, e.g. truncated ones
+			continue
+		}
+
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package casaos is a Go client for CasaOS, talking to its services via the gateway over plain HTTP.
+//
+// It is what the casaos-cli commands use to talk to CasaOS, and can be used by other tools as well:
+//
+//	client, err := casaos.NewClient("localhost:80")
+//	if err != nil {
+//		return err
+//	}
+//
+//	apps, err := client.Apps().List(ctx)
+//
+// The package only depends on types defined here, so it can be imported without generating any API client.
+package casaos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	BasePathAppManagement = "v2/app_management"
+	BasePathLocalStorage  = "v2/local_storage"
+	BasePathMessageBus    = "v2/message_bus"
+
+	mimeApplicationJSON = "application/json"
+	mimeApplicationYAML = "application/yaml"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Client is a client for all CasaOS services behind the gateway at root url.
+type Client struct {
+	rootURL     string
+	httpClient  *http.Client
+	token       string
+	hostname    string
+	dialContext func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for all requests. By default http.DefaultClient is used.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the access token attached to all requests as `Authorization` header.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithDialContext sets the function to open connections for message bus websockets, e.g. through an SSH tunnel.
+// By default they are dialed directly. HTTP requests are sent with the HTTP client instead, see WithHTTPClient.
+func WithDialContext(dialContext func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	return func(c *Client) {
+		c.dialContext = dialContext
+	}
+}
+
+// WithHostname sets the host in web UI urls of apps, e.g. the LAN address of CasaOS when the root url is on loopback.
+// By default the host of the root url is used.
+func WithHostname(hostname string) Option {
+	return func(c *Client) {
+		c.hostname = hostname
+	}
+}
+
+// NewClient creates a client for CasaOS at root url, e.g. `localhost:80`.
+func NewClient(rootURL string, opts ...Option) (*Client, error) {
+	rootURL = strings.TrimRight(strings.TrimPrefix(rootURL, "http://"), "/")
+	if rootURL == "" {
+		return nil, fmt.Errorf("root url is empty")
+	}
+
+	hostname := rootURL
+	if host, _, err := net.SplitHostPort(rootURL); err == nil {
+		hostname = host
+	}
+
+	c := &Client{
+		rootURL:    rootURL,
+		httpClient: http.DefaultClient,
+		hostname:   hostname,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// RootURL returns the root url of CasaOS, without scheme.
+func (c *Client) RootURL() string {
+	return c.rootURL
+}
+
+// Apps returns the service for compose apps.
+func (c *Client) Apps() *AppsService {
+	return &AppsService{client: c}
+}
+
+// Bus returns the service for message bus.
+func (c *Client) Bus() *BusService {
+	return &BusService{client: c}
+}
+
+// Health returns the service for health of the CasaOS host.
+func (c *Client) Health() *HealthService {
+	return &HealthService{client: c}
+}
+
+// Store returns the service for apps in app stores.
+func (c *Client) Store() *StoreService {
+	return &StoreService{client: c}
+}
+
+// Storage returns the service for local storage.
+func (c *Client) Storage() *StorageService {
+	return &StorageService{client: c}
+}
+
+// Users returns the service for the current user.
+func (c *Client) Users() *UsersService {
+	return &UsersService{client: c}
+}
+
+// StatusError is returned when a CasaOS service responds with an unsuccessful HTTP status.
+type StatusError struct {
+	// StatusCode is the HTTP status code, e.g. 404.
+	StatusCode int
+
+	// Status is the HTTP status, e.g. `404 Not Found`.
+	Status string
+
+	// Message is the `message` field of the response body, or the body itself if there is no such field.
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s - %s", e.Status, e.Message)
+}
+
+// NewStatusError builds a StatusError from an unsuccessful response of the service, e.g. `casaos-app-management`.
+func NewStatusError(statusCode int, status string, body []byte, service string) *StatusError {
+	message := json.Get(body, "message").ToString()
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("is the %s service running?", service)
+	}
+
+	return &StatusError{StatusCode: statusCode, Status: status, Message: message}
+}
+
+// apiRequest is a request to a service behind the gateway.
+type apiRequest struct {
+	method string
+
+	// path is relative to the root url, including the base path of the service, e.g. `v2/app_management/compose`.
+	path  string
+	query url.Values
+
+	body        io.Reader
+	contentType string
+	accept      string
+
+	// service is the name of the service, for error messages.
+	service string
+}
+
+// do sends the request, and returns the response body if the request succeeded, or a StatusError otherwise.
+func (c *Client) do(ctx context.Context, r apiRequest) ([]byte, error) {
+	requestURL := fmt.Sprintf("http://%s/%s", c.rootURL, strings.TrimLeft(r.path, "/"))
+	if len(r.query) > 0 {
+		requestURL += "?" + r.query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, r.method, requestURL, r.body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.contentType != "" {
+		request.Header.Set("Content-Type", r.contentType)
+	}
+
+	if r.accept != "" {
+		request.Header.Set("Accept", r.accept)
+	}
+
+	if c.token != "" {
+		request.Header.Set("Authorization", c.token)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, NewStatusError(response.StatusCode, response.Status, buf, r.service)
+	}
+
+	return buf, nil
+}
+
+// jsonBody encodes v as a JSON request body.
+func jsonBody(v interface{}) (io.Reader, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(buf), nil
+}
+
+// message returns the `message` field of a response body.
+func message(body []byte) string {
+	return json.Get(body, "message").ToString()
+}
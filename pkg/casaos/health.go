@@ -0,0 +1,69 @@
+package casaos
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	BasePathCasaOS = "v2/casaos"
+
+	serviceCasaOS = "casaos"
+)
+
+// HealthServices is running status of `casaos-*` services, by their systemd unit names, e.g. `casaos-gateway.service`.
+type HealthServices struct {
+	Running    []string `json:"running"`
+	NotRunning []string `json:"not_running"`
+}
+
+// HealthPorts are ports in use on the CasaOS host, by protocol.
+type HealthPorts struct {
+	TCP []int `json:"tcp"`
+	UDP []int `json:"udp"`
+}
+
+// HealthService checks health of the CasaOS host via the casaos service.
+type HealthService struct {
+	client *Client
+}
+
+// Services returns running status of `casaos-*` services.
+func (s *HealthService) Services(ctx context.Context) (*HealthServices, error) {
+	var response struct {
+		Data HealthServices `json:"data"`
+	}
+
+	if err := s.get(ctx, "services", &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// Ports returns ports in use on the CasaOS host.
+func (s *HealthService) Ports(ctx context.Context) (*HealthPorts, error) {
+	var response struct {
+		Data HealthPorts `json:"data"`
+	}
+
+	if err := s.get(ctx, "ports", &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// Logs returns logs of all `casaos-*` services, as a ZIP file with one log file per service.
+func (s *HealthService) Logs(ctx context.Context) ([]byte, error) {
+	return s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathCasaOS + "/health/logs", service: serviceCasaOS})
+}
+
+func (s *HealthService) get(ctx context.Context, name string, v interface{}) error {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathCasaOS + "/health/" + name, service: serviceCasaOS})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(buf, v)
+}
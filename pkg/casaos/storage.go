@@ -0,0 +1,115 @@
+package casaos
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const serviceLocalStorage = "casaos-local-storage"
+
+// Merge is a merged mount of source volumes, e.g. by mergerfs.
+type Merge struct {
+	ID int `json:"id,omitempty"`
+
+	// FSType is the file system type of the merge, e.g. `fuse.mergerfs`. When setting a merge, the default of
+	// local storage is used if empty.
+	FSType string `json:"fstype,omitempty"`
+
+	MountPoint        string   `json:"mount_point"`
+	SourceBasePath    string   `json:"source_base_path,omitempty"`
+	SourceVolumeUUIDs []string `json:"source_volume_uuids,omitempty"`
+
+	// CreatedAt and UpdatedAt are zero if not reported by local storage.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StorageService manages disks, volumes and merges via the local storage service.
+type StorageService struct {
+	client *Client
+}
+
+// Merges returns all merges.
+func (s *StorageService) Merges(ctx context.Context) ([]Merge, error) {
+	return s.merges(ctx, url.Values{})
+}
+
+// Merge returns the merge at the mount point, or nil if not found.
+func (s *StorageService) Merge(ctx context.Context, mountPoint string) (*Merge, error) {
+	merges, err := s.merges(ctx, url.Values{"mount_point": {mountPoint}})
+	if err != nil {
+		return nil, err
+	}
+
+	// older versions of local storage ignore the mount point and return all merges
+	for i := range merges {
+		if merges[i].MountPoint == mountPoint {
+			return &merges[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SetMerge creates the merge, or replaces the one at the same mount point, and returns it as it is stored.
+func (s *StorageService) SetMerge(ctx context.Context, merge Merge) (*Merge, error) {
+	// only fields that can be set are sent, e.g. not timestamps
+	body, err := jsonBody(struct {
+		FSType            string   `json:"fstype,omitempty"`
+		MountPoint        string   `json:"mount_point"`
+		SourceBasePath    string   `json:"source_base_path,omitempty"`
+		SourceVolumeUUIDs []string `json:"source_volume_uuids"`
+	}{
+		FSType:            merge.FSType,
+		MountPoint:        merge.MountPoint,
+		SourceBasePath:    merge.SourceBasePath,
+		SourceVolumeUUIDs: merge.SourceVolumeUUIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := s.client.do(ctx, apiRequest{
+		method:      http.MethodPost,
+		path:        BasePathLocalStorage + "/merge",
+		body:        body,
+		contentType: mimeApplicationJSON,
+		service:     serviceLocalStorage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data *Merge `json:"data"`
+	}
+
+	if err := json.Unmarshal(buf, &response); err != nil || response.Data == nil {
+		return &merge, nil
+	}
+
+	return response.Data, nil
+}
+
+func (s *StorageService) merges(ctx context.Context, query url.Values) ([]Merge, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathLocalStorage + "/merge", query: query, service: serviceLocalStorage})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data []Merge `json:"data"`
+	}
+
+	if err := json.Unmarshal(buf, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Data == nil {
+		return []Merge{}, nil
+	}
+
+	return response.Data, nil
+}
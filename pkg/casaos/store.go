@@ -0,0 +1,208 @@
+package casaos
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// AppStore is an app store registered to app management.
+type AppStore struct {
+	ID        int
+	URL       string
+	StoreRoot string
+}
+
+// StoreService manages app stores registered to the app management service, and queries their apps.
+type StoreService struct {
+	client *Client
+}
+
+// Stores returns registered app stores, sorted by ID.
+func (s *StoreService) Stores(ctx context.Context) ([]AppStore, error) {
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathAppManagement + "/appstore", service: serviceAppManagement})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data []struct {
+			URL       string `json:"url"`
+			StoreRoot string `json:"store_root"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(buf, &response); err != nil {
+		return nil, err
+	}
+
+	// app stores are identified by their position in the list
+	stores := make([]AppStore, 0, len(response.Data))
+	for id, store := range response.Data {
+		stores = append(stores, AppStore{ID: id, URL: store.URL, StoreRoot: store.StoreRoot})
+	}
+
+	return stores, nil
+}
+
+// Register registers an app store by the URL of its ZIP file, and returns the message from app management.
+func (s *StoreService) Register(ctx context.Context, storeURL string) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:  http.MethodPost,
+		path:    BasePathAppManagement + "/appstore",
+		query:   url.Values{"url": {storeURL}},
+		service: serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Unregister unregisters an app store by its ID, and returns the message from app management.
+func (s *StoreService) Unregister(ctx context.Context, id int) (string, error) {
+	buf, err := s.client.do(ctx, apiRequest{
+		method:  http.MethodDelete,
+		path:    BasePathAppManagement + "/appstore/" + strconv.Itoa(id),
+		service: serviceAppManagement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return message(buf), nil
+}
+
+// Compose returns the compose YAML of a store app, exactly as it is submitted when the app is installed.
+func (s *StoreService) Compose(ctx context.Context, storeAppID string) ([]byte, error) {
+	return s.client.do(ctx, apiRequest{
+		method:  http.MethodGet,
+		path:    BasePathAppManagement + "/apps/" + url.PathEscape(storeAppID) + "/compose",
+		accept:  mimeApplicationYAML,
+		service: serviceAppManagement,
+	})
+}
+
+// StoreApp is an app in registered app stores.
+type StoreApp struct {
+	ID        string
+	Category  string
+	Author    string
+	Developer string
+	Tags      []string
+
+	// Title, Tagline and Description of the app, by language.
+	Title       map[string]string
+	Tagline     map[string]string
+	Description map[string]string
+
+	Installed   bool
+	Recommended bool
+
+	// RecommendRank is the position of the app in the recommend list of app stores, starting from 1, or 0 if it is
+	// not recommended.
+	RecommendRank int
+}
+
+// Author types of store apps, for StoreAppFilter.
+const (
+	AuthorTypeOfficial  = "official"
+	AuthorTypeByCasaOS  = "by_casaos"
+	AuthorTypeCommunity = "community"
+)
+
+// StoreAppFilter selects apps in app stores. All apps are selected if empty.
+type StoreAppFilter struct {
+	Category string
+
+	// AuthorType is one of AuthorTypeOfficial, AuthorTypeByCasaOS and AuthorTypeCommunity.
+	AuthorType string
+
+	Recommend bool
+}
+
+// Apps returns apps in registered app stores, sorted by ID.
+func (s *StoreService) Apps(ctx context.Context, filter StoreAppFilter) ([]StoreApp, error) {
+	query := url.Values{}
+
+	if filter.Category != "" {
+		query.Set("category", filter.Category)
+	}
+
+	if filter.AuthorType != "" {
+		query.Set("author_type", filter.AuthorType)
+	}
+
+	if filter.Recommend {
+		query.Set("recommend", "true")
+	}
+
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathAppManagement + "/apps", query: query, service: serviceAppManagement})
+	if err != nil {
+		return nil, err
+	}
+
+	data := json.Get(buf, "data")
+
+	installed := map[string]bool{}
+	for _, id := range stringList(data.Get("installed")) {
+		installed[id] = true
+	}
+
+	recommendRanks := map[string]int{}
+	for i, id := range stringList(data.Get("recommend")) {
+		recommendRanks[id] = i + 1
+	}
+
+	list := data.Get("list")
+
+	ids := list.Keys()
+	sort.Strings(ids)
+
+	apps := make([]StoreApp, 0, len(ids))
+
+	for _, id := range ids {
+		storeInfo := list.Get(id)
+
+		apps = append(apps, StoreApp{
+			ID:            id,
+			Category:      storeInfo.Get("category").ToString(),
+			Author:        storeInfo.Get("author").ToString(),
+			Developer:     storeInfo.Get("developer").ToString(),
+			Tags:          stringList(storeInfo.Get("tags")),
+			Title:         languageMap(storeInfo.Get("title")),
+			Tagline:       languageMap(storeInfo.Get("tagline")),
+			Description:   languageMap(storeInfo.Get("description")),
+			Installed:     installed[id],
+			Recommended:   recommendRanks[id] > 0,
+			RecommendRank: recommendRanks[id],
+		})
+	}
+
+	return apps, nil
+}
+
+// stringList returns strings in a JSON array, or in arrays by language, e.g. `{"en_us": ["a", "b"]}`.
+func stringList(value jsoniter.Any) []string {
+	values := []string{}
+
+	switch value.ValueType() {
+	case jsoniter.ArrayValue:
+		for i := 0; i < value.Size(); i++ {
+			values = append(values, value.Get(i).ToString())
+		}
+	case jsoniter.ObjectValue:
+		for _, key := range value.Keys() {
+			values = append(values, stringList(value.Get(key))...)
+		}
+	case jsoniter.StringValue:
+		values = append(values, value.ToString())
+	}
+
+	return values
+}
@@ -0,0 +1,76 @@
+package casaos
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	BasePathUsers = "v2/users"
+
+	serviceUserService = "casaos-user-service"
+)
+
+// UserEvent is an event received by the current user, as stored by the user service.
+type UserEvent struct {
+	UUID       string                 `json:"uuid"`
+	SourceID   string                 `json:"sourceID"`
+	Name       string                 `json:"name"`
+	Properties map[string]interface{} `json:"properties"`
+	Timestamp  time.Time              `json:"timestamp"`
+}
+
+// UserEventsFilter selects events received by the current user. Zero values select all events. Versions of the user
+// service that do not support a filter return all events, so callers should apply the filter to the result as well.
+type UserEventsFilter struct {
+	SourceID string
+	Name     string
+	Since    time.Time
+	Until    time.Time
+}
+
+// UsersService manages the current user via the user service.
+type UsersService struct {
+	client *Client
+}
+
+// Events returns events received by the current user.
+func (s *UsersService) Events(ctx context.Context, filter UserEventsFilter) ([]UserEvent, error) {
+	query := url.Values{}
+
+	if filter.SourceID != "" {
+		query.Set("source_id", filter.SourceID)
+	}
+
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+
+	buf, err := s.client.do(ctx, apiRequest{method: http.MethodGet, path: BasePathUsers + "/event", query: query, service: serviceUserService})
+	if err != nil {
+		return nil, err
+	}
+
+	events := []UserEvent{}
+	if err := json.Unmarshal(buf, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// DeleteEvent deletes an event received by the current user by its UUID.
+func (s *UsersService) DeleteEvent(ctx context.Context, uuid string) error {
+	_, err := s.client.do(ctx, apiRequest{method: http.MethodDelete, path: BasePathUsers + "/event/" + url.PathEscape(uuid), service: serviceUserService})
+	return err
+}